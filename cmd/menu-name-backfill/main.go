@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"eka-dev.cloud/transaction-service/config"
+	"eka-dev.cloud/transaction-service/modules/transaction"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+const batchSize = 100
+
+// menu_name baru diisi saat checkout sejak full-text search ditambahkan, detail lama diisi dari
+// master-data lewat command ini. search_vector ikut terisi karena kolom generated
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "backfill" {
+		log.Fatal("usage: go run main.go backfill")
+	}
+
+	db, err := sqlx.Connect("postgres", config.Config.DBUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(db *sqlx.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Println("failed to close database:", err)
+		}
+	}(db)
+
+	service := transaction.NewTransactionService(transaction.NewTransactionRepository(db), db)
+
+	// menu_id dibaca berurutan supaya menu yang tidak ada lagi di master-data tidak diulang terus
+	lastMenuId := 0
+	for {
+		var menuIds []int
+		err := db.Select(&menuIds, `SELECT DISTINCT menu_id FROM td_user_checkouts
+			WHERE menu_name IS NULL AND menu_id > $1 ORDER BY menu_id LIMIT $2`, lastMenuId, batchSize)
+		if err != nil {
+			log.Fatal("failed to get menu ids: ", err)
+		}
+		if len(menuIds) == 0 {
+			break
+		}
+		lastMenuId = menuIds[len(menuIds)-1]
+
+		ids := make([]string, 0, len(menuIds))
+		for _, id := range menuIds {
+			ids = append(ids, strconv.Itoa(id))
+		}
+
+		data, err := service.GetMenusAndTable(strings.Join(ids, ","), "")
+		if err != nil {
+			log.Fatal("failed to get menus from master-data: ", err)
+		}
+
+		for _, menu := range data.Menus {
+			result, err := db.Exec(`UPDATE td_user_checkouts SET menu_name = $1 WHERE menu_id = $2 AND menu_name IS NULL`, menu.Name, menu.Id)
+			if err != nil {
+				log.Fatalf("failed to backfill menu %d: %v", menu.Id, err)
+			}
+			affected, _ := result.RowsAffected()
+			fmt.Printf("✅ menu %d (%s): %d rows\n", menu.Id, menu.Name, affected)
+		}
+		if len(data.Menus) < len(menuIds) {
+			fmt.Printf("⚠️ %d menus not found in master-data, menu_name stays empty\n", len(menuIds)-len(data.Menus))
+		}
+	}
+}
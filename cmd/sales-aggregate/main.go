@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"eka-dev.cloud/transaction-service/config"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+const dateLayout = "2006-01-02"
+
+func main() {
+	if len(os.Args) < 4 || os.Args[1] != "rebuild" {
+		log.Fatal("usage: go run main.go rebuild <start-date> <end-date>")
+	}
+
+	startDate, err := time.Parse(dateLayout, os.Args[2])
+	if err != nil {
+		log.Fatal("invalid start date: ", err)
+	}
+	endDate, err := time.Parse(dateLayout, os.Args[3])
+	if err != nil {
+		log.Fatal("invalid end date: ", err)
+	}
+	if endDate.Before(startDate) {
+		log.Fatal("end date must be after start date")
+	}
+
+	db, err := sqlx.Connect("postgres", config.Config.DBUrl)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func(db *sqlx.DB) {
+		err := db.Close()
+		if err != nil {
+			log.Println("failed to close database:", err)
+		}
+	}(db)
+
+	// rebuild per hari supaya lock di tabel agregat tidak lama, checkout yang berjalan bersamaan
+	// hanya menunggu rebuild satu hari selesai (lihat lock di rebuild_sales_aggregates)
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		day := date.Format(dateLayout)
+		_, err := db.Exec("SELECT rebuild_sales_aggregates(CAST($1 AS DATE), CAST($1 AS DATE))", day)
+		if err != nil {
+			log.Fatalf("failed to rebuild %s: %v", day, err)
+		}
+		fmt.Println("✅ rebuilt", day)
+	}
+}
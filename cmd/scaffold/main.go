@@ -12,13 +12,34 @@ import (
 )
 
 var templates = map[string]string{
+	"constant.go": `package {{.Name}}
+
+// TODO: sesuaikan nama tabel dan kolom
+const baseQuery = ` + "`" + `
+SELECT
+	t.id
+	FROM {{.Name}} t
+	` + "`" + `
+
+const countQuery = "SELECT COUNT(t.id) FROM {{.Name}} t "
+
+var mappingFieds = map[string]string{
+	"id": "t.id",
+}
+var mappingFiedType = map[string]string{
+	"t.id": "int",
+}
+`,
 	"repository.go": `package {{.Name}}
 
 import (
+	"eka-dev.cloud/transaction-service/utils/common"
+	"eka-dev.cloud/transaction-service/utils/response"
 	"github.com/jmoiron/sqlx"
 )
 
 type Repository interface {
+	GetList{{.StructName}}(params common.ParamsListRequest) (*response.Pagination[[]{{.StructName}}Response], error)
 	// TODO: define repository methods
 }
 
@@ -29,14 +50,30 @@ type {{.StructNameLower}}Repository struct {
 func New{{.StructName}}Repository(db *sqlx.DB) Repository {
 	return &{{.StructNameLower}}Repository{db: db}
 }
+
+func (r *{{.StructNameLower}}Repository) GetList{{.StructName}}(params common.ParamsListRequest) (*response.Pagination[[]{{.StructName}}Response], error) {
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
+
+	return common.QueryPagination[{{.StructName}}Response](r.db, params, common.PaginationQuery{
+		Name:             "{{.Name}}",
+		Query:            baseQuery,
+		CountQuery:       countQuery,
+		MappingFieldType: &mappingFiedType,
+	})
+}
 `,
 	"service.go": `package {{.Name}}
 
 import (
+	"eka-dev.cloud/transaction-service/utils/common"
+	"eka-dev.cloud/transaction-service/utils/response"
 	"github.com/jmoiron/sqlx"
 )
 
 type Service interface {
+	GetList{{.StructName}}(request common.ParamsListRequest) (*response.Pagination[[]{{.StructName}}Response], error)
 	// TODO: define service methods
 }
 
@@ -48,15 +85,22 @@ type {{.StructNameLower}}Service struct {
 func New{{.StructName}}Service(repo Repository, db *sqlx.DB) Service {
 	return &{{.StructNameLower}}Service{repo: repo, db: db}
 }
+
+func (s *{{.StructNameLower}}Service) GetList{{.StructName}}(request common.ParamsListRequest) (*response.Pagination[[]{{.StructName}}Response], error) {
+	return s.repo.GetList{{.StructName}}(request)
+}
 `,
 	"route.go": `package {{.Name}}
 
 import (
+	"eka-dev.cloud/transaction-service/utils/common"
+	"eka-dev.cloud/transaction-service/utils/response"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jmoiron/sqlx"
 )
 
 type Handler interface {
+	GetList{{.StructName}}(c *fiber.Ctx) error
 	// TODO: define handler methods
 }
 
@@ -71,13 +115,32 @@ func NewHandler(app *fiber.App, db *sqlx.DB) Handler {
 	h := &handler{service: service, db: db}
 
 	routes := app.Group("/api/1.0/{{.Name}}")
-	// routes.Get("", h.GetSomething)
+	routes.Get("", h.GetList{{.StructName}})
 
 	return h
 }
+
+func (h *handler) GetList{{.StructName}}(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request common.ParamsListRequest
+	if err := common.ParseQueryParams(c, &request); err != nil {
+		return err
+	}
+
+	records, err := h.service.GetList{{.StructName}}(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
 `,
 	"dto.go": `package {{.Name}}
 
+type {{.StructName}}Response struct {
+	Id int64 ` + "`" + `json:"id" db:"id"` + "`" + `
+}
+
 // TODO: define DTOs here
 `,
 }
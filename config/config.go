@@ -29,6 +29,13 @@ type appConfig struct {
 	AllowedOrigins       string
 	RabbitmqUrl          string
 	ServiceAccountUrl    string
+	RatingWindowDays     int
+	ExportBatchSize      int
+	MaxPageSize          int
+	OutletName           string
+	OutletAddress        string
+	OutletPhone          string
+	ReceiptCacheEnabled  bool
 }
 
 var Config appConfig
@@ -38,6 +45,10 @@ func init() {
 	log.Println("Loading .env file")
 	viper.SetConfigFile(".env") // atau bisa juga pakai viper.SetConfigName("app") + viper.AddConfigPath(".")
 	viper.AutomaticEnv()        // override dengan ENV OS kalau ada
+	viper.SetDefault("RATING_WINDOW_DAYS", 7)
+	viper.SetDefault("EXPORT_BATCH_SIZE", 500)
+	viper.SetDefault("MAX_PAGE_SIZE", 100)
+	viper.SetDefault("OUTLET_NAME", "Exa Coffee")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Println("No .env file found, fallback to system environment")
@@ -65,5 +76,12 @@ func init() {
 		AllowedOrigins:       viper.GetString("ALLOWED_ORIGINS"),
 		RabbitmqUrl:          viper.GetString("RABBITMQ_URL"),
 		ServiceAccountUrl:    viper.GetString("SERVICE_ACCOUNT_URL"),
+		RatingWindowDays:     viper.GetInt("RATING_WINDOW_DAYS"),
+		ExportBatchSize:      viper.GetInt("EXPORT_BATCH_SIZE"),
+		MaxPageSize:          viper.GetInt("MAX_PAGE_SIZE"),
+		OutletName:           viper.GetString("OUTLET_NAME"),
+		OutletAddress:        viper.GetString("OUTLET_ADDRESS"),
+		OutletPhone:          viper.GetString("OUTLET_PHONE"),
+		ReceiptCacheEnabled:  viper.GetBool("RECEIPT_CACHE_ENABLED"),
 	}
 }
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS th_user_carts;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE th_user_carts
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT NOT NULL UNIQUE,
+    table_id   INT       DEFAULT NULL,
+    order_for  VARCHAR   DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
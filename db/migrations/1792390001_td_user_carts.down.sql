@@ -0,0 +1 @@
+DROP TABLE IF EXISTS td_user_carts;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE td_user_carts
+(
+    id         SERIAL PRIMARY KEY,
+    ref_id     INT NOT NULL,
+    menu_id    INT NOT NULL,
+    qty        INT NOT NULL,
+    notes      TEXT      DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE td_user_carts
+    ADD CONSTRAINT FK_TD_USER_CARTS_TH_USER_CARTS FOREIGN KEY (ref_id) REFERENCES th_user_carts (id) ON DELETE CASCADE;
+
+ALTER TABLE td_user_carts
+    ADD CONSTRAINT UQ_TD_USER_CARTS_REF_MENU UNIQUE (ref_id, menu_id);
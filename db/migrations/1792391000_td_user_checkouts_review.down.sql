@@ -0,0 +1,3 @@
+ALTER TABLE td_user_checkouts
+    DROP COLUMN IF EXISTS review_photos,
+    DROP COLUMN IF EXISTS review;
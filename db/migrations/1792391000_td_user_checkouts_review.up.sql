@@ -0,0 +1,3 @@
+ALTER TABLE td_user_checkouts
+    ADD COLUMN review        TEXT  DEFAULT NULL,
+    ADD COLUMN review_photos JSONB DEFAULT NULL;
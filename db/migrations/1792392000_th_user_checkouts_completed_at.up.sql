@@ -0,0 +1,6 @@
+ALTER TABLE th_user_checkouts
+    ADD COLUMN completed_at TIMESTAMP DEFAULT NULL;
+
+UPDATE th_user_checkouts
+SET completed_at = updated_at
+WHERE order_status = 2;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS IDX_TH_USER_CHECKOUTS_SERVICE_RATED_AT;
+
+ALTER TABLE th_user_checkouts
+    DROP COLUMN IF EXISTS service_rated_at,
+    DROP COLUMN IF EXISTS service_comment,
+    DROP COLUMN IF EXISTS friendliness_rating,
+    DROP COLUMN IF EXISTS speed_rating;
@@ -0,0 +1,8 @@
+ALTER TABLE th_user_checkouts
+    ADD COLUMN speed_rating        INT       DEFAULT NULL,
+    ADD COLUMN friendliness_rating INT       DEFAULT NULL,
+    ADD COLUMN service_comment     TEXT      DEFAULT NULL,
+    ADD COLUMN service_rated_at    TIMESTAMP DEFAULT NULL;
+
+CREATE INDEX IDX_TH_USER_CHECKOUTS_SERVICE_RATED_AT ON th_user_checkouts (service_rated_at)
+    WHERE service_rated_at IS NOT NULL;
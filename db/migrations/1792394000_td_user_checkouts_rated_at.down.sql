@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS IDX_TD_USER_CHECKOUTS_RATED_AT;
+
+ALTER TABLE td_user_checkouts
+    DROP COLUMN IF EXISTS rated_at;
@@ -0,0 +1,10 @@
+ALTER TABLE td_user_checkouts
+    ADD COLUMN rated_at TIMESTAMP DEFAULT NULL;
+
+UPDATE td_user_checkouts
+SET rated_at = updated_at
+WHERE rating IS NOT NULL;
+
+CREATE INDEX IDX_TD_USER_CHECKOUTS_RATED_AT ON td_user_checkouts (rated_at, menu_id)
+    INCLUDE (rating)
+    WHERE rating IS NOT NULL;
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS TRG_TH_USER_CHECKOUTS_SALES_UPDATE ON th_user_checkouts;
+DROP TRIGGER IF EXISTS TRG_TH_USER_CHECKOUTS_SALES_INSERT_DELETE ON th_user_checkouts;
+DROP FUNCTION IF EXISTS th_user_checkouts_sales_aggregate();
+DROP FUNCTION IF EXISTS rebuild_sales_aggregates(DATE, DATE);
+DROP FUNCTION IF EXISTS apply_sales_delta(TIMESTAMP, DECIMAL, INT);
+DROP INDEX IF EXISTS IDX_TH_USER_CHECKOUTS_CREATED_AT;
+DROP TABLE IF EXISTS tr_sales_daily;
+DROP TABLE IF EXISTS tr_sales_hourly;
@@ -0,0 +1,107 @@
+CREATE TABLE tr_sales_hourly
+(
+    sales_hour  TIMESTAMP PRIMARY KEY,
+    total       DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    total_order INT            NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE tr_sales_daily
+(
+    sales_date  DATE PRIMARY KEY,
+    total       DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    total_order INT            NOT NULL DEFAULT 0,
+    updated_at  TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS IDX_TH_USER_CHECKOUTS_CREATED_AT ON th_user_checkouts (created_at);
+
+-- apply_sales_delta menambah/mengurangi agregat jam dan hari dari satu checkout
+CREATE OR REPLACE FUNCTION apply_sales_delta(p_created_at TIMESTAMP, p_total DECIMAL, p_order INT)
+    RETURNS VOID AS
+$$
+BEGIN
+    INSERT INTO tr_sales_hourly (sales_hour, total, total_order)
+    VALUES (DATE_TRUNC('hour', p_created_at), p_total, p_order)
+    ON CONFLICT (sales_hour) DO UPDATE
+        SET total       = tr_sales_hourly.total + EXCLUDED.total,
+            total_order = tr_sales_hourly.total_order + EXCLUDED.total_order,
+            updated_at  = CURRENT_TIMESTAMP;
+
+    INSERT INTO tr_sales_daily (sales_date, total, total_order)
+    VALUES (CAST(p_created_at AS DATE), p_total, p_order)
+    ON CONFLICT (sales_date) DO UPDATE
+        SET total       = tr_sales_daily.total + EXCLUDED.total,
+            total_order = tr_sales_daily.total_order + EXCLUDED.total_order,
+            updated_at  = CURRENT_TIMESTAMP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION th_user_checkouts_sales_aggregate()
+    RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.created_at IS NOT NULL THEN
+        PERFORM apply_sales_delta(OLD.created_at, -COALESCE(OLD.total_price, 0), -1);
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.created_at IS NOT NULL THEN
+        PERFORM apply_sales_delta(NEW.created_at, COALESCE(NEW.total_price, 0), 1);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- update status tidak mengubah agregat, jadi trigger update hanya untuk kolom yang dihitung
+CREATE TRIGGER TRG_TH_USER_CHECKOUTS_SALES_INSERT_DELETE
+    AFTER INSERT OR DELETE
+    ON th_user_checkouts
+    FOR EACH ROW
+EXECUTE FUNCTION th_user_checkouts_sales_aggregate();
+
+CREATE TRIGGER TRG_TH_USER_CHECKOUTS_SALES_UPDATE
+    AFTER UPDATE OF created_at, total_price
+    ON th_user_checkouts
+    FOR EACH ROW
+    WHEN (OLD.created_at IS DISTINCT FROM NEW.created_at OR OLD.total_price IS DISTINCT FROM NEW.total_price)
+EXECUTE FUNCTION th_user_checkouts_sales_aggregate();
+
+-- rebuild_sales_aggregates menghitung ulang agregat untuk rentang tanggal [p_start_date, p_end_date]
+CREATE OR REPLACE FUNCTION rebuild_sales_aggregates(p_start_date DATE, p_end_date DATE)
+    RETURNS VOID AS
+$$
+BEGIN
+    -- SHARE ROW EXCLUSIVE bentrok dengan upsert dari trigger checkout, jadi trigger menunggu
+    -- rebuild selesai dan rebuild menunggu checkout yang belum commit. Tanpa lock ini insert
+    -- rebuild bisa kena unique violation atau increment dari trigger hilang
+    LOCK TABLE tr_sales_hourly, tr_sales_daily IN SHARE ROW EXCLUSIVE MODE;
+
+    DELETE
+    FROM tr_sales_hourly
+    WHERE sales_hour >= p_start_date
+      AND sales_hour < p_end_date + 1;
+
+    DELETE
+    FROM tr_sales_daily
+    WHERE sales_date BETWEEN p_start_date AND p_end_date;
+
+    INSERT INTO tr_sales_hourly (sales_hour, total, total_order)
+    SELECT DATE_TRUNC('hour', t.created_at), COALESCE(SUM(t.total_price), 0), COUNT(t.id)
+    FROM th_user_checkouts t
+    WHERE t.created_at >= p_start_date
+      AND t.created_at < p_end_date + 1
+    GROUP BY 1;
+
+    INSERT INTO tr_sales_daily (sales_date, total, total_order)
+    SELECT CAST(h.sales_hour AS DATE), SUM(h.total), SUM(h.total_order)
+    FROM tr_sales_hourly h
+    WHERE h.sales_hour >= p_start_date
+      AND h.sales_hour < p_end_date + 1
+    GROUP BY 1;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT rebuild_sales_aggregates(CAST(MIN(created_at) AS DATE), CAST(MAX(created_at) AS DATE))
+FROM th_user_checkouts
+HAVING COUNT(id) > 0;
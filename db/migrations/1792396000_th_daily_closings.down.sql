@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS th_daily_closings;
+
+ALTER TABLE th_user_checkouts
+    DROP COLUMN IF EXISTS payment_method;
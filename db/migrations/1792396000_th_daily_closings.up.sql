@@ -0,0 +1,14 @@
+ALTER TABLE th_user_checkouts
+    ADD COLUMN payment_method VARCHAR(20) NOT NULL DEFAULT 'wallet';
+
+CREATE TABLE th_daily_closings
+(
+    id                SERIAL PRIMARY KEY,
+    closing_date      DATE           NOT NULL UNIQUE,
+    total_order       INT            NOT NULL DEFAULT 0,
+    gross             DECIMAL(14, 2) NOT NULL DEFAULT 0,
+    by_payment_method JSONB          NOT NULL DEFAULT '[]',
+    notes             TEXT           DEFAULT NULL,
+    closed_at         TIMESTAMP      DEFAULT CURRENT_TIMESTAMP,
+    closed_by         INT            NOT NULL
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS IDX_TD_USER_CHECKOUTS_REF_ID;
+DROP INDEX IF EXISTS IDX_TD_USER_CHECKOUTS_SEARCH_VECTOR;
+
+ALTER TABLE td_user_checkouts
+    DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE td_user_checkouts
+    DROP COLUMN IF EXISTS menu_name;
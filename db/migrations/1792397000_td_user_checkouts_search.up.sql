@@ -0,0 +1,12 @@
+-- menu_name detail lama diisi dari master-data lewat cmd/menu-name-backfill (lihat DOCS_MIGRATION.md)
+ALTER TABLE td_user_checkouts
+    ADD COLUMN menu_name VARCHAR DEFAULT NULL;
+
+-- config 'simple' dipakai karena nama menu dan catatan campuran bahasa Indonesia dan Inggris
+ALTER TABLE td_user_checkouts
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        TO_TSVECTOR('simple', COALESCE(menu_name, '') || ' ' || COALESCE(notes, ''))
+        ) STORED;
+
+CREATE INDEX IDX_TD_USER_CHECKOUTS_SEARCH_VECTOR ON td_user_checkouts USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS IDX_TD_USER_CHECKOUTS_REF_ID ON td_user_checkouts (ref_id);
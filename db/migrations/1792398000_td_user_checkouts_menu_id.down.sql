@@ -0,0 +1 @@
+DROP INDEX IF EXISTS IDX_TD_USER_CHECKOUTS_MENU_ID;
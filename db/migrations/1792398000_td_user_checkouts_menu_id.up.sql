@@ -0,0 +1 @@
+CREATE INDEX IDX_TD_USER_CHECKOUTS_MENU_ID ON td_user_checkouts (menu_id, ref_id);
@@ -0,0 +1,2 @@
+ALTER TABLE th_user_checkouts
+    DROP COLUMN IF EXISTS payment_reference;
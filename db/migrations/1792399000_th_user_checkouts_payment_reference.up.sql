@@ -0,0 +1,2 @@
+ALTER TABLE th_user_checkouts
+    ADD COLUMN payment_reference VARCHAR(100) DEFAULT NULL;
@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatXLSX   = "xlsx"
+	ExportFormatNDJSON = "ndjson"
+
+	MIMETextCSV = "text/csv"
+	MIMEXLSX    = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIMENDJSON  = "application/x-ndjson"
+	MIMEPDF     = "application/pdf"
+)
+
+// CSVRecord mengubah satu baris export menjadi record CSV
+func CSVRecord(row []interface{}) []string {
+	record := make([]string, len(row))
+	for i, value := range row {
+		record[i] = fmt.Sprint(value)
+	}
+	return record
+}
+
+// XLSXWriter menulis rows ke satu sheet XLSX per batch. Excelize StreamWriter menyimpan row ke
+// file sementara kalau sudah besar, jadi export panjang tidak ditahan seluruhnya di memory
+type XLSXWriter struct {
+	file   *excelize.File
+	writer *excelize.StreamWriter
+	row    int
+}
+
+// NewXLSXWriter membuat file XLSX baru dan langsung menulis header. Close wajib dipanggil
+func NewXLSXWriter(sheetName string, header []string) (*XLSXWriter, error) {
+	file := excelize.NewFile()
+	xlsx := &XLSXWriter{file: file}
+
+	if err := file.SetSheetName(file.GetSheetName(0), sheetName); err != nil {
+		xlsx.Close()
+		log.Error("Failed to set xlsx sheet name:", err)
+		return nil, response.InternalServerError("Failed to generate xlsx", nil)
+	}
+
+	writer, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		xlsx.Close()
+		log.Error("Failed to create xlsx stream writer:", err)
+		return nil, response.InternalServerError("Failed to generate xlsx", nil)
+	}
+	xlsx.writer = writer
+
+	headerCells := make([]interface{}, len(header))
+	for i, value := range header {
+		headerCells[i] = value
+	}
+	if err := xlsx.WriteRows([][]interface{}{headerCells}); err != nil {
+		xlsx.Close()
+		return nil, err
+	}
+
+	return xlsx, nil
+}
+
+// WriteRows menambah rows setelah row terakhir yang sudah ditulis
+func (x *XLSXWriter) WriteRows(rows [][]interface{}) error {
+	for _, cells := range rows {
+		x.row++
+		cell, err := excelize.CoordinatesToCellName(1, x.row)
+		if err != nil {
+			log.Error("Failed to get xlsx cell name:", err)
+			return response.InternalServerError("Failed to generate xlsx", nil)
+		}
+		if err := x.writer.SetRow(cell, cells); err != nil {
+			log.Error("Failed to write xlsx row:", err)
+			return response.InternalServerError("Failed to generate xlsx", nil)
+		}
+	}
+	return nil
+}
+
+// Flush menutup sheet, dipanggil sekali setelah semua rows ditulis dan sebelum WriteTo
+func (x *XLSXWriter) Flush() error {
+	if err := x.writer.Flush(); err != nil {
+		log.Error("Failed to flush xlsx rows:", err)
+		return response.InternalServerError("Failed to generate xlsx", nil)
+	}
+	return nil
+}
+
+// WriteTo menulis file XLSX ke writer, contoh body stream response
+func (x *XLSXWriter) WriteTo(w io.Writer) (int64, error) {
+	return x.file.WriteTo(w)
+}
+
+// Close menghapus file sementara milik StreamWriter
+func (x *XLSXWriter) Close() {
+	if err := x.file.Close(); err != nil {
+		log.Error("Failed to close xlsx file:", err)
+	}
+}
@@ -1,7 +1,9 @@
 package lib
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"mime/multipart"
 
 	"eka-dev.cloud/transaction-service/config"
@@ -56,6 +58,51 @@ func UploadFile(filePath string, fileHeader *multipart.FileHeader) (string, erro
 	return url, nil
 }
 
+func UploadBytes(filePath string, data []byte, contentType string) (string, error) {
+	bucketName := config.Config.MinioBucketName
+	ctx := context.Background()
+
+	info, err := minioClient.PutObject(ctx, bucketName, filePath, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		log.Error("Failed to upload file to MinIO:", err)
+		return "", response.InternalServerError("Failed to upload file", nil)
+	}
+
+	url := config.Config.MinioBaseURL + "/" + bucketName + "/" + info.Key
+	return url, nil
+}
+
+// GetFile mengambil isi file dari MinIO, nil kalau file belum ada
+func GetFile(filePath string) ([]byte, error) {
+	bucketName := config.Config.MinioBucketName
+	ctx := context.Background()
+
+	object, err := minioClient.GetObject(ctx, bucketName, filePath, minio.GetObjectOptions{})
+	if err != nil {
+		log.Error("Failed to get file from MinIO:", err)
+		return nil, response.InternalServerError("Failed to get file", nil)
+	}
+	defer func(object *minio.Object) {
+		err := object.Close()
+		if err != nil {
+			log.Error("Failed to close MinIO object:", err)
+		}
+	}(object)
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil
+		}
+		log.Error("Failed to read file from MinIO:", err)
+		return nil, response.InternalServerError("Failed to get file", nil)
+	}
+
+	return data, nil
+}
+
 func DeleteFile(filePath string) error {
 	bucketName := config.Config.MinioBucketName
 
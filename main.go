@@ -10,6 +10,7 @@ import (
 	"eka-dev.cloud/transaction-service/lib"
 	_ "eka-dev.cloud/transaction-service/lib"
 	"eka-dev.cloud/transaction-service/middleware"
+	"eka-dev.cloud/transaction-service/modules/cart"
 	"eka-dev.cloud/transaction-service/modules/transaction"
 	"eka-dev.cloud/transaction-service/utils/response"
 	"github.com/gofiber/fiber/v2"
@@ -71,6 +72,8 @@ func initiator() {
 	// Initialize routes
 	// Menus
 	transaction.NewHandler(fiberApp, db.DB)
+	// Carts
+	cart.NewHandler(fiberApp, db.DB)
 
 	fiberApp.All("*", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(response.NotFound("Route not found", nil))
@@ -0,0 +1,21 @@
+package cart
+
+const baseQuery = `
+SELECT
+	c.id,
+	COALESCE(c.table_id, 0) AS table_id,
+	COALESCE(c.order_for, '') AS order_for,
+	COALESCE(
+		JSON_AGG(
+			JSON_BUILD_OBJECT(
+				'id', ci.id,
+				'menuId', ci.menu_id,
+				'qty', ci.qty,
+				'notes', COALESCE(ci.notes, '')
+			) ORDER BY ci.id
+		) FILTER (WHERE ci.id IS NOT NULL),
+		'[]'
+	) AS items
+	FROM th_user_carts c
+LEFT JOIN td_user_carts ci ON c.id = ci.ref_id
+	`
@@ -0,0 +1,72 @@
+package cart
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type AddCartItemRequest struct {
+	MenuId int    `json:"menuId" validate:"required"`
+	Qty    int    `json:"qty" validate:"required,gt=0"`
+	Notes  string `json:"notes"`
+	UserId int64  `json:"userId"`
+}
+
+type UpdateCartItemRequest struct {
+	Id     int    `json:"id" validate:"required"`
+	Qty    int    `json:"qty" validate:"required,gt=0"`
+	Notes  string `json:"notes"`
+	UserId int64  `json:"userId"`
+}
+
+type DeleteCartItemRequest struct {
+	Id     int   `json:"id" validate:"required"`
+	UserId int64 `json:"userId"`
+}
+
+type SetCartTableRequest struct {
+	TableId  int64  `json:"tableId" validate:"required"`
+	OrderFor string `json:"orderFor" validate:"required"`
+	UserId   int64  `json:"userId"`
+}
+
+type CheckoutCartRequest struct {
+	Pin    string `json:"pin" validate:"required,len=6,numeric"`
+	UserId int64  `json:"userId"`
+}
+
+type CartResponse struct {
+	Id        int64          `json:"id" db:"id"`
+	TableId   int64          `json:"tableId" db:"table_id"`
+	TableName string         `json:"tableName"`
+	OrderFor  string         `json:"orderFor" db:"order_for"`
+	Total     float64        `json:"total"`
+	Items     JSONBCartItems `json:"items" db:"items"`
+}
+
+type JSONBCartItems []CartItem
+
+func (d *JSONBCartItems) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to type assert value to []byte")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+type CartItem struct {
+	Id          int     `json:"id"`
+	MenuId      int     `json:"menuId"`
+	Qty         int     `json:"qty"`
+	Notes       string  `json:"notes"`
+	Price       float64 `json:"price"`
+	TotalPrice  float64 `json:"totalPrice"`
+	MenuName    string  `json:"menuName"`
+	Description string  `json:"description"`
+	Photo       string  `json:"photo"`
+	Available   bool    `json:"available"`
+}
@@ -0,0 +1,154 @@
+package cart
+
+import (
+	"database/sql"
+	"errors"
+
+	"eka-dev.cloud/transaction-service/utils/common"
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetCartByUserId(userId int64) (*CartResponse, error)
+	GetCartForCheckout(tx *sqlx.Tx, userId int64) (*CartResponse, error)
+	UpsertCart(tx *sqlx.Tx, userId int64) (int, error)
+	SetCartTable(tx *sqlx.Tx, request SetCartTableRequest) error
+	AddCartItem(tx *sqlx.Tx, cartId int, request AddCartItemRequest) error
+	UpdateCartItem(tx *sqlx.Tx, request UpdateCartItemRequest) error
+	DeleteCartItem(tx *sqlx.Tx, request DeleteCartItemRequest) error
+	ClearCart(tx *sqlx.Tx, userId int64) error
+}
+
+type cartRepository struct {
+	db *sqlx.DB
+}
+
+func NewCartRepository(db *sqlx.DB) Repository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) GetCartByUserId(userId int64) (*CartResponse, error) {
+	var record CartResponse
+	query := baseQuery + " WHERE c.user_id = $1 GROUP BY c.id "
+
+	err := r.db.Get(&record, query, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &CartResponse{Items: JSONBCartItems{}}, nil
+		}
+		log.Error("Failed to get cart:", err)
+		return nil, response.InternalServerError("Failed to get cart", nil)
+	}
+
+	return &record, nil
+}
+
+func (r *cartRepository) GetCartForCheckout(tx *sqlx.Tx, userId int64) (*CartResponse, error) {
+	var cartId int64
+	// lock header cart supaya checkout paralel tidak membuat transaksi ganda
+	err := tx.Get(&cartId, `SELECT id FROM th_user_carts WHERE user_id = $1 FOR UPDATE`, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, response.BadRequest("Cart is empty", nil)
+		}
+		log.Error("Failed to lock cart:", err)
+		return nil, response.InternalServerError("Failed to get cart", nil)
+	}
+
+	var record CartResponse
+	query := baseQuery + " WHERE c.id = $1 GROUP BY c.id "
+
+	err = tx.Get(&record, query, cartId)
+	if err != nil {
+		log.Error("Failed to get cart:", err)
+		return nil, response.InternalServerError("Failed to get cart", nil)
+	}
+
+	return &record, nil
+}
+
+func (r *cartRepository) UpsertCart(tx *sqlx.Tx, userId int64) (int, error) {
+	var id int
+	query := `INSERT INTO th_user_carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = CURRENT_TIMESTAMP RETURNING id`
+
+	err := tx.QueryRow(query, userId).Scan(&id)
+	if err != nil {
+		log.Error("Failed to upsert cart:", err)
+		return 0, response.InternalServerError("Failed to save cart", nil)
+	}
+	return id, nil
+}
+
+func (r *cartRepository) SetCartTable(tx *sqlx.Tx, request SetCartTableRequest) error {
+	query := `INSERT INTO th_user_carts (user_id, table_id, order_for) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET table_id = EXCLUDED.table_id, order_for = EXCLUDED.order_for, updated_at = CURRENT_TIMESTAMP`
+
+	_, err := tx.Exec(query, request.UserId, request.TableId, request.OrderFor)
+	if err != nil {
+		log.Error("Failed to set cart table:", err)
+		return response.InternalServerError("Failed to set cart table", nil)
+	}
+	return nil
+}
+
+func (r *cartRepository) AddCartItem(tx *sqlx.Tx, cartId int, request AddCartItemRequest) error {
+	query := `INSERT INTO td_user_carts (ref_id, menu_id, qty, notes) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (ref_id, menu_id) DO UPDATE SET qty = td_user_carts.qty + EXCLUDED.qty, notes = EXCLUDED.notes, updated_at = CURRENT_TIMESTAMP`
+
+	_, err := tx.Exec(query, cartId, request.MenuId, request.Qty, request.Notes)
+	if err != nil {
+		log.Error("Failed to add cart item:", err)
+		return response.InternalServerError("Failed to add cart item", nil)
+	}
+	return nil
+}
+
+func (r *cartRepository) UpdateCartItem(tx *sqlx.Tx, request UpdateCartItemRequest) error {
+	query := `UPDATE td_user_carts ci SET qty = $1, notes = $2, updated_at = CURRENT_TIMESTAMP
+		FROM th_user_carts c WHERE ci.ref_id = c.id AND ci.id = $3 AND c.user_id = $4`
+
+	result, err := tx.Exec(query, request.Qty, request.Notes, request.Id, request.UserId)
+	if err != nil {
+		log.Error("Failed to update cart item:", err)
+		return response.InternalServerError("Failed to update cart item", nil)
+	}
+
+	return validateAffectedRows(result, "Cart item not found")
+}
+
+func (r *cartRepository) DeleteCartItem(tx *sqlx.Tx, request DeleteCartItemRequest) error {
+	query := `DELETE FROM td_user_carts ci USING th_user_carts c WHERE ci.ref_id = c.id AND ci.id = $1 AND c.user_id = $2`
+
+	result, err := tx.Exec(query, request.Id, request.UserId)
+	if err != nil {
+		log.Error("Failed to delete cart item:", err)
+		return response.InternalServerError("Failed to delete cart item", nil)
+	}
+
+	return validateAffectedRows(result, "Cart item not found")
+}
+
+func (r *cartRepository) ClearCart(tx *sqlx.Tx, userId int64) error {
+	query := `DELETE FROM th_user_carts WHERE user_id = $1`
+
+	_, err := tx.Exec(query, userId)
+	if err != nil {
+		log.Error("Failed to clear cart:", err)
+		return response.InternalServerError("Failed to clear cart", nil)
+	}
+	return nil
+}
+
+func validateAffectedRows(info sql.Result, message string) error {
+	affected, err := common.GetInfoRowsAffected(info)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return response.NotFound(message, nil)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package cart
+
+import (
+	"eka-dev.cloud/transaction-service/lib"
+	"eka-dev.cloud/transaction-service/middleware"
+	"eka-dev.cloud/transaction-service/modules/transaction"
+	"eka-dev.cloud/transaction-service/utils/common"
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/jmoiron/sqlx"
+)
+
+type Handler interface {
+	GetCart(c *fiber.Ctx) error
+	AddCartItem(c *fiber.Ctx) error
+	UpdateCartItem(c *fiber.Ctx) error
+	DeleteCartItem(c *fiber.Ctx) error
+	SetCartTable(c *fiber.Ctx) error
+	ClearCart(c *fiber.Ctx) error
+	CheckoutCart(c *fiber.Ctx) error
+}
+
+type handler struct {
+	service Service
+	db      *sqlx.DB
+}
+
+func NewHandler(app *fiber.App, db *sqlx.DB) Handler {
+	repo := NewCartRepository(db)
+	transactionService := transaction.NewTransactionService(transaction.NewTransactionRepository(db), db)
+	service := NewCartService(repo, transactionService, db)
+	h := &handler{service: service, db: db}
+
+	routes := app.Group("/api/1.0/carts")
+	routes.Get("", middleware.RequireAuth, h.GetCart)
+	routes.Delete("", middleware.RequireAuth, h.ClearCart)
+	routes.Post("/items", middleware.RequireAuth, h.AddCartItem)
+	routes.Patch("/items", middleware.RequireAuth, h.UpdateCartItem)
+	routes.Delete("/items", middleware.RequireAuth, h.DeleteCartItem)
+	routes.Patch("/table", middleware.RequireAuth, h.SetCartTable)
+	routes.Post("/checkout", middleware.RequireAuth, h.CheckoutCart)
+
+	return h
+}
+
+func (h *handler) GetCart(c *fiber.Ctx) error {
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	record, err := h.service.GetCart(claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", record))
+}
+
+func (h *handler) AddCartItem(c *fiber.Ctx) error {
+	// Parse request body
+	var request AddCartItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.UserId = claims.UserId
+
+	err = common.WithTransaction[AddCartItemRequest](h.db, h.service.AddCartItem, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.Success("Cart item added successfully", nil))
+}
+
+func (h *handler) UpdateCartItem(c *fiber.Ctx) error {
+	// Parse request body
+	var request UpdateCartItemRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.UserId = claims.UserId
+
+	err = common.WithTransaction[UpdateCartItemRequest](h.db, h.service.UpdateCartItem, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Cart item updated successfully", nil))
+}
+
+func (h *handler) DeleteCartItem(c *fiber.Ctx) error {
+	// Parse query parameter
+	oneRequest, err := common.GetOneDataRequest(c)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request := DeleteCartItemRequest{
+		Id:     oneRequest.Id,
+		UserId: claims.UserId,
+	}
+
+	err = common.WithTransaction[DeleteCartItemRequest](h.db, h.service.DeleteCartItem, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Cart item deleted successfully", nil))
+}
+
+func (h *handler) SetCartTable(c *fiber.Ctx) error {
+	// Parse request body
+	var request SetCartTableRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.UserId = claims.UserId
+
+	err = common.WithTransaction[SetCartTableRequest](h.db, h.service.SetCartTable, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Cart table updated successfully", nil))
+}
+
+func (h *handler) ClearCart(c *fiber.Ctx) error {
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	err = common.WithTransaction[int64](h.db, h.service.ClearCart, claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Cart cleared successfully", nil))
+}
+
+func (h *handler) CheckoutCart(c *fiber.Ctx) error {
+	// Parse request body
+	var request CheckoutCartRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.UserId = claims.UserId
+
+	err = common.WithTransaction[CheckoutCartRequest](h.db, h.service.CheckoutCart, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.Success("Transaction created successfully", nil))
+}
@@ -0,0 +1,145 @@
+package cart
+
+import (
+	"strings"
+
+	"eka-dev.cloud/transaction-service/modules/transaction"
+	"eka-dev.cloud/transaction-service/utils"
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/jmoiron/sqlx"
+)
+
+type Service interface {
+	GetCart(userId int64) (*CartResponse, error)
+	AddCartItem(tx *sqlx.Tx, request AddCartItemRequest) error
+	UpdateCartItem(tx *sqlx.Tx, request UpdateCartItemRequest) error
+	DeleteCartItem(tx *sqlx.Tx, request DeleteCartItemRequest) error
+	SetCartTable(tx *sqlx.Tx, request SetCartTableRequest) error
+	ClearCart(tx *sqlx.Tx, userId int64) error
+	CheckoutCart(tx *sqlx.Tx, request CheckoutCartRequest) error
+}
+
+type cartService struct {
+	repo               Repository
+	transactionService transaction.Service
+	db                 *sqlx.DB
+}
+
+func NewCartService(repo Repository, transactionService transaction.Service, db *sqlx.DB) Service {
+	return &cartService{repo: repo, transactionService: transactionService, db: db}
+}
+
+func (s *cartService) GetCart(userId int64) (*CartResponse, error) {
+	res, err := s.repo.GetCartByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Items) == 0 {
+		return res, nil
+	}
+
+	menuIds := make([]string, 0, len(res.Items))
+	for _, item := range res.Items {
+		menuIds = append(menuIds, utils.IntToString(item.MenuId))
+	}
+
+	tableIdStr := ""
+	if res.TableId != 0 {
+		tableIdStr = utils.Int64ToString(res.TableId)
+	}
+
+	dataMenusAndTable, err := s.transactionService.GetMenusAndTable(strings.Join(menuIds, ","), tableIdStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// harga selalu diambil dari master-data, bukan disimpan di cart
+	res.Total = 0
+	for i, item := range res.Items {
+		for _, menu := range dataMenusAndTable.Menus {
+			if item.MenuId == menu.Id {
+				res.Items[i].Price = menu.Price
+				res.Items[i].TotalPrice = menu.Price * float64(item.Qty)
+				res.Items[i].MenuName = menu.Name
+				res.Items[i].Description = menu.Description
+				res.Items[i].Photo = menu.Photo
+				res.Items[i].Available = true
+				res.Total += res.Items[i].TotalPrice
+				break
+			}
+		}
+	}
+
+	for _, table := range dataMenusAndTable.Tables {
+		if res.TableId == table.Id {
+			res.TableName = table.Name
+			break
+		}
+	}
+
+	return res, nil
+}
+
+func (s *cartService) AddCartItem(tx *sqlx.Tx, request AddCartItemRequest) error {
+	cartId, err := s.repo.UpsertCart(tx, request.UserId)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.AddCartItem(tx, cartId, request)
+}
+
+func (s *cartService) UpdateCartItem(tx *sqlx.Tx, request UpdateCartItemRequest) error {
+	return s.repo.UpdateCartItem(tx, request)
+}
+
+func (s *cartService) DeleteCartItem(tx *sqlx.Tx, request DeleteCartItemRequest) error {
+	return s.repo.DeleteCartItem(tx, request)
+}
+
+func (s *cartService) SetCartTable(tx *sqlx.Tx, request SetCartTableRequest) error {
+	return s.repo.SetCartTable(tx, request)
+}
+
+func (s *cartService) ClearCart(tx *sqlx.Tx, userId int64) error {
+	return s.repo.ClearCart(tx, userId)
+}
+
+func (s *cartService) CheckoutCart(tx *sqlx.Tx, request CheckoutCartRequest) error {
+	cart, err := s.repo.GetCartForCheckout(tx, request.UserId)
+	if err != nil {
+		return err
+	}
+
+	if len(cart.Items) == 0 {
+		return response.BadRequest("Cart is empty", nil)
+	}
+
+	if cart.TableId == 0 || cart.OrderFor == "" {
+		return response.BadRequest("Table and order for must be set before checkout", nil)
+	}
+
+	transactionRequest := transaction.CreateTransactionRequest{
+		TableId:   cart.TableId,
+		OrderFor:  cart.OrderFor,
+		Pin:       request.Pin,
+		Datas:     make([]transaction.Data, 0, len(cart.Items)),
+		CreatedBy: request.UserId,
+	}
+	for _, item := range cart.Items {
+		transactionRequest.Datas = append(transactionRequest.Datas, transaction.Data{
+			MenuID: item.MenuId,
+			Qty:    item.Qty,
+			Notes:  item.Notes,
+		})
+	}
+
+	err = s.transactionService.CreateTransaction(tx, transactionRequest)
+	if err != nil {
+		return err
+	}
+
+	// cart dihapus di transaksi database yang sama dengan insert checkout
+	return s.repo.ClearCart(tx, request.UserId)
+}
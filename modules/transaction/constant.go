@@ -8,6 +8,8 @@ SELECT
 	t.order_status,
 	t.user_id,
 	t.order_for,
+	t.payment_method,
+	COALESCE(t.payment_reference, '') AS payment_reference,
 	t.created_at,
 	t.updated_at,
 	JSON_AGG(
@@ -17,21 +19,359 @@ SELECT
             'price', td.price,
             'id', td.id,
             'notes', td.notes,
+            'menuName', COALESCE(td.menu_name, ''),
             'totalPrice', td.total_price,
-            'rating', td.rating                    
+            'rating', td.rating,
+            'review', COALESCE(td.review, ''),
+            'reviewPhotos', COALESCE(td.review_photos, CAST('[]' AS JSONB))
         )
     ) AS details
 	FROM th_user_checkouts t
 JOIN td_user_checkouts td ON t.id = td.ref_id
 	`
 
+// textSearchCondition mencari transaksi yang punya detail dengan catatan atau nama menu yang cocok
+const textSearchCondition = ` EXISTS (SELECT 1 FROM td_user_checkouts tds WHERE tds.ref_id = t.id AND tds.search_vector @@ TO_TSQUERY('simple', :text_query)) `
+
+// textSearchRank dipakai untuk urutan relevansi, hanya valid di query yang join td dan GROUP BY t.id
+const textSearchRank = `MAX(TS_RANK(td.search_vector, TO_TSQUERY('simple', :text_query)))`
+
+// nilai param expand untuk data relasi dari master-data dan account
+const (
+	expandMenus = "menus"
+	expandTable = "table"
+	expandUser  = "user"
+)
+
 var mappingFieds = map[string]string{
 	"id":          "t.id",
 	"orderStatus": "t.order_status",
 	"orderFor":    "t.order_for",
+	"totalPrice":  "t.total_price",
+	"tableId":     "t.table_id",
+	"userId":      "t.user_id",
+	"createdAt":   "t.created_at",
+	"createdDate": "DATE(t.created_at)",
 }
 var mappingFiedType = map[string]string{
-	"t.id":           "int",
-	"t.order_status": "int",
-	"t.order_for":    "string",
+	"t.id":               "int",
+	"t.order_status":     "int",
+	"t.order_for":        "string",
+	"t.total_price":      "decimal",
+	"t.table_id":         "int",
+	"t.user_id":          "int",
+	"t.created_at":       "timestamp",
+	"DATE(t.created_at)": "date",
+}
+
+const (
+	maxRatingPhotos = 3
+	ratingPhotoPath = "ratings"
+)
+
+const receiptPath = "receipts"
+
+const (
+	orderStatusPending    = 0
+	orderStatusProcessing = 1
+	orderStatusCompleted  = 2
+)
+
+// defaultPaymentMethod dipakai kalau wallet service tidak mengirim metode pembayaran,
+// sama dengan default kolom payment_method
+const defaultPaymentMethod = "wallet"
+
+var orderStatusLabels = map[int64]string{
+	orderStatusPending:    "pending",
+	orderStatusProcessing: "processing",
+	orderStatusCompleted:  "completed",
+}
+
+const (
+	queueSetRatingMenu    = "menu.set_rating"
+	queueUpdateRatingMenu = "menu.update_rating"
+
+	queueSetServiceRating    = "transaction.set_service_rating"
+	queueUpdateServiceRating = "transaction.update_service_rating"
+)
+
+const serviceRatingQuery = `
+SELECT
+	t.id,
+	t.user_id,
+	t.table_id,
+	t.total_price,
+	t.speed_rating,
+	t.friendliness_rating,
+	(t.speed_rating + t.friendliness_rating) / 2.0 AS score,
+	COALESCE(t.service_comment, '') AS service_comment,
+	t.service_rated_at
+	FROM th_user_checkouts t
+WHERE t.service_rated_at IS NOT NULL
+	`
+
+const ratingStatsQuery = `
+SELECT
+	td.menu_id,
+	ROUND(AVG(td.rating), 2) AS average,
+	COUNT(td.rating) AS total_rating,
+	COUNT(*) FILTER (WHERE td.rating = 1) AS rating_1,
+	COUNT(*) FILTER (WHERE td.rating = 2) AS rating_2,
+	COUNT(*) FILTER (WHERE td.rating = 3) AS rating_3,
+	COUNT(*) FILTER (WHERE td.rating = 4) AS rating_4,
+	COUNT(*) FILTER (WHERE td.rating = 5) AS rating_5
+	FROM td_user_checkouts td
+WHERE td.rating IS NOT NULL
+	AND td.rated_at >= CAST(:start_date AS DATE)
+	AND td.rated_at < CAST(:end_date AS DATE) + 1
+	`
+
+const (
+	granularityHour  = "hour"
+	granularityDay   = "day"
+	granularityWeek  = "week"
+	granularityMonth = "month"
+
+	maxHourlyReportDays = 31
+)
+
+// localCreatedAt mengubah created_at (disimpan di timezone database) ke timezone outlet
+const localCreatedAt = `(t.created_at AT TIME ZONE current_setting('TimeZone') AT TIME ZONE COALESCE(NULLIF(:timezone, ''), current_setting('TimeZone')))`
+
+// localSalesHour mengubah sales_hour di tr_sales_hourly ke timezone outlet
+const localSalesHour = `(s.sales_hour AT TIME ZONE current_setting('TimeZone') AT TIME ZONE COALESCE(NULLIF(:timezone, ''), current_setting('TimeZone')))`
+
+// summary report dibaca dari tabel agregat (tr_sales_daily/tr_sales_hourly) yang diisi trigger
+// di th_user_checkouts, bukan dari tabel checkout langsung.
+// Bucket dibuat bucket sampai detik terakhir endDate supaya
+// granularity hour tetap berisi jam 00:00 sampai 23:00 di hari terakhir
+const summaryReportBucketsQuery = `
+WITH buckets AS (
+	SELECT GENERATE_SERIES(
+		DATE_TRUNC(:granularity, CAST(:start_date AS TIMESTAMP)),
+		DATE_TRUNC(:granularity, CAST(:end_date AS DATE) + INTERVAL '1 day' - INTERVAL '1 second'),
+		CAST('1 ' || :granularity AS INTERVAL)
+	) AS bucket
+), totals AS (%s)
+SELECT
+	b.bucket AS created_at,
+	COALESCE(tt.total, 0) AS total,
+	COALESCE(tt.total_order, 0) AS total_order
+	FROM buckets b
+LEFT JOIN totals tt ON tt.bucket = b.bucket
+ORDER BY b.bucket
+	`
+
+const summaryReportDailyTotals = `
+	SELECT
+		DATE_TRUNC(:granularity, CAST(s.sales_date AS TIMESTAMP)) AS bucket,
+		SUM(s.total) AS total,
+		SUM(s.total_order) AS total_order
+	FROM tr_sales_daily s
+	WHERE s.sales_date BETWEEN CAST(:start_date AS DATE) AND CAST(:end_date AS DATE)
+	GROUP BY 1
+`
+
+const summaryReportHourlyTotals = `
+	SELECT
+		DATE_TRUNC(:granularity, ` + localSalesHour + `) AS bucket,
+		SUM(s.total) AS total,
+		SUM(s.total_order) AS total_order
+	FROM tr_sales_hourly s
+	WHERE ` + localSalesHour + ` >= CAST(:start_date AS DATE)
+		AND ` + localSalesHour + ` < CAST(:end_date AS DATE) + 1
+	GROUP BY 1
+`
+
+const defaultTopMenuLimit = 10
+
+var topMenuSortFields = map[string]string{
+	"qty":     "total_qty",
+	"revenue": "revenue",
+	"orders":  "total_order",
+}
+
+const topMenusQuery = `
+SELECT
+	td.menu_id,
+	SUM(td.qty) AS total_qty,
+	SUM(td.total_price) AS revenue,
+	COUNT(DISTINCT td.ref_id) AS total_order,
+	COALESCE(ROUND(SUM(td.total_price) * 100 / NULLIF(SUM(SUM(td.total_price)) OVER (), 0), 2), 0) AS share
+	FROM td_user_checkouts td
+JOIN th_user_checkouts t ON t.id = td.ref_id
+WHERE t.created_at >= CAST(:start_date AS DATE)
+	AND t.created_at < CAST(:end_date AS DATE) + 1
+GROUP BY td.menu_id
+	`
+
+// weekday mengikuti ISODOW: 1 = Senin sampai 7 = Minggu
+var peakHoursWeekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// peak hours dibaca dari tr_sales_hourly, waktu persiapan (checkout sampai status completed)
+// dijumlahkan trigger di prep_seconds untuk order yang sudah completed
+const peakHoursQuery = `
+SELECT
+	CAST(EXTRACT(ISODOW FROM ` + localSalesHour + `) AS INT) AS weekday,
+	CAST(EXTRACT(HOUR FROM ` + localSalesHour + `) AS INT) AS hour,
+	SUM(s.total_order) AS total_order,
+	COALESCE(SUM(s.total), 0) AS total,
+	ROUND(CAST(SUM(s.prep_seconds) / NULLIF(SUM(s.completed_order), 0) / 60 AS NUMERIC), 2) AS avg_prep_minutes
+	FROM tr_sales_hourly s
+WHERE ` + localSalesHour + ` >= CAST(:start_date AS DATE)
+	AND ` + localSalesHour + ` < CAST(:end_date AS DATE) + 1
+GROUP BY 1, 2
+HAVING SUM(s.total_order) > 0
+	`
+
+// cohort ditentukan dari bulan order pertama user, order sebelum startDate tetap dihitung
+// supaya user lama tidak terbaca sebagai customer baru
+const cohortReportQuery = `
+WITH orders AS (
+	SELECT
+		t.user_id,
+		t.total_price,
+		DATE_TRUNC('month', ` + localCreatedAt + `) AS order_month
+	FROM th_user_checkouts t
+	WHERE ` + localCreatedAt + ` < CAST(:end_date AS DATE) + 1
+), cohort_orders AS (
+	SELECT
+		o.user_id,
+		o.total_price,
+		MIN(o.order_month) OVER (PARTITION BY o.user_id) AS cohort_month,
+		o.order_month
+	FROM orders o
+), user_orders AS (
+	SELECT
+		co.cohort_month,
+		co.user_id,
+		COUNT(*) AS total_order,
+		SUM(co.total_price) AS total
+	FROM cohort_orders co
+	WHERE co.cohort_month >= DATE_TRUNC('month', CAST(:start_date AS TIMESTAMP))
+	GROUP BY co.cohort_month, co.user_id
+), retention AS (
+	SELECT
+		co.cohort_month,
+		CAST(EXTRACT(YEAR FROM AGE(co.order_month, co.cohort_month)) * 12 + EXTRACT(MONTH FROM AGE(co.order_month, co.cohort_month)) AS INT) AS month_offset,
+		COUNT(DISTINCT co.user_id) AS customers
+	FROM cohort_orders co
+	WHERE co.cohort_month >= DATE_TRUNC('month', CAST(:start_date AS TIMESTAMP))
+	GROUP BY 1, 2
+)
+SELECT
+	TO_CHAR(u.cohort_month, 'YYYY-MM') AS cohort,
+	COUNT(u.user_id) AS customers,
+	COUNT(u.user_id) FILTER (WHERE u.total_order > 1) AS repeat_customers,
+	ROUND(COUNT(u.user_id) FILTER (WHERE u.total_order > 1) * 100.0 / COUNT(u.user_id), 2) AS repeat_rate,
+	SUM(u.total_order) AS total_order,
+	COALESCE(SUM(u.total), 0) AS total,
+	COALESCE(ROUND(SUM(u.total) / NULLIF(SUM(u.total_order), 0), 2), 0) AS average_order_value,
+	ROUND(SUM(u.total_order) * 1.0 / COUNT(u.user_id), 2) AS frequency,
+	(
+		SELECT JSON_AGG(JSON_BUILD_OBJECT(
+			'monthOffset', r.month_offset,
+			'customers', r.customers,
+			'rate', ROUND(r.customers * 100.0 / COUNT(u.user_id), 2)
+		) ORDER BY r.month_offset)
+		FROM retention r
+		WHERE r.cohort_month = u.cohort_month
+	) AS retention
+	FROM user_orders u
+GROUP BY u.cohort_month
+ORDER BY u.cohort_month
+	`
+
+// dailyClosingLockKey adalah key advisory lock per tanggal closing. Checkout dan update status
+// mengambil lock shared, closing mengambil lock exclusive, jadi closing menunggu transaksi yang
+// sedang berjalan di tanggal itu dan transaksi baru menunggu closing selesai
+const dailyClosingLockKey = `hashtext('daily_closing:' || CAST(%s AS TEXT))`
+
+// dailyClosingTotalsQuery menghitung total satu hari (timezone database) untuk closing.
+// Belum ada flow cancel/refund order, jadi closing hanya mencatat order dan gross
+const dailyClosingTotalsQuery = `
+WITH day_orders AS (
+	SELECT t.id, t.order_status, t.total_price, t.payment_method
+	FROM th_user_checkouts t
+	WHERE t.created_at >= CAST($1 AS DATE)
+		AND t.created_at < CAST($1 AS DATE) + 1
+)
+SELECT
+	COUNT(d.id) FILTER (WHERE d.order_status < $2) AS total_unfinished,
+	COUNT(d.id) AS total_order,
+	COALESCE(SUM(d.total_price), 0) AS gross,
+	COALESCE((
+		SELECT JSON_AGG(JSON_BUILD_OBJECT(
+			'paymentMethod', p.payment_method,
+			'totalOrder', p.total_order,
+			'total', p.total
+		) ORDER BY p.payment_method)
+		FROM (
+			SELECT payment_method, COUNT(id) AS total_order, SUM(total_price) AS total
+			FROM day_orders
+			GROUP BY payment_method
+		) p
+	), '[]') AS by_payment_method
+	FROM day_orders d
+	`
+
+const dailyClosingQuery = `
+SELECT
+	c.id,
+	TO_CHAR(c.closing_date, 'YYYY-MM-DD') AS closing_date,
+	c.total_order,
+	c.gross,
+	c.by_payment_method,
+	COALESCE(c.notes, '') AS notes,
+	c.closed_at,
+	c.closed_by
+	FROM th_daily_closings c
+	`
+
+const (
+	dimensionTable  = "table"
+	dimensionStatus = "status"
+	dimensionStaff  = "staff"
+	dimensionHour   = "hour"
+
+	maxBreakdownDimensions = 2
+)
+
+// whitelist dimensi breakdown, hanya ekspresi di sini yang boleh masuk ke query
+var salesBreakdownDimensions = map[string]string{
+	dimensionTable:  "t.table_id",
+	dimensionStatus: "t.order_status",
+	dimensionStaff:  "COALESCE(t.updated_by, 0)",
+	dimensionHour:   "CAST(EXTRACT(HOUR FROM " + localCreatedAt + ") AS INT)",
+}
+
+// dimensi yang bisa dihitung dari tr_sales_hourly, breakdown yang hanya memakai dimensi ini
+// tidak perlu scan th_user_checkouts. table, status dan staff tidak ada di agregat
+var salesBreakdownAggregateDimensions = map[string]string{
+	dimensionHour: "CAST(EXTRACT(HOUR FROM " + localSalesHour + ") AS INT)",
+}
+
+// exportErrorMarker ditulis sebagai baris terakhir CSV kalau stream gagal di tengah jalan,
+// status 200 sudah terkirim jadi client harus cek baris ini untuk tahu file tidak lengkap
+const (
+	exportErrorMarker  = "#ERROR"
+	exportErrorMessage = "Export interrupted, data is incomplete"
+)
+
+var transactionExportHeader = []string{
+	"Transaction ID",
+	"Created At",
+	"Table",
+	"Customer",
+	"Order For",
+	"Order Status",
+	"Transaction Total",
+	"Detail ID",
+	"Menu ID",
+	"Menu Name",
+	"Qty",
+	"Price",
+	"Line Total",
+	"Notes",
+	"Rating",
 }
@@ -1,8 +1,10 @@
 package transaction
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 
 	"eka-dev.cloud/transaction-service/utils/common"
 )
@@ -44,20 +46,22 @@ type UserResponse struct {
 }
 
 type Data struct {
-	MenuID int     `json:"menuId" validate:"required"`
-	Qty    int     `json:"qty" validate:"required,gt=0"`
-	Notes  string  `json:"notes" `
-	Price  float64 `json:"price"`
-	Total  float64 `json:"total"`
+	MenuID   int     `json:"menuId" validate:"required"`
+	Qty      int     `json:"qty" validate:"required,gt=0"`
+	Notes    string  `json:"notes" `
+	Price    float64 `json:"price"`
+	Total    float64 `json:"total"`
+	MenuName string  `json:"-"`
 }
 
 type CreateTransactionRequest struct {
-	TableId   int64   `json:"tableId" validate:"required"`
-	OrderFor  string  `json:"orderFor" validate:"required"`
-	Pin       string  `json:"pin" validate:"required,len=6,numeric"`
-	Datas     []Data  `json:"datas" validate:"required,dive,required"`
-	Total     float64 `json:"total"`
-	CreatedBy int64   `json:"createdBy"`
+	TableId   int64           `json:"tableId" validate:"required"`
+	OrderFor  string          `json:"orderFor" validate:"required"`
+	Pin       string          `json:"pin" validate:"required,len=6,numeric"`
+	Datas     []Data          `json:"datas" validate:"required,dive,required"`
+	Total     float64         `json:"total"`
+	CreatedBy int64           `json:"createdBy"`
+	Payment   PaymentResponse `json:"-"`
 }
 
 type PaymentRequest struct {
@@ -66,18 +70,51 @@ type PaymentRequest struct {
 	Pin    string  `json:"pin"`
 }
 
+type InternalPaymentResponse struct {
+	Data PaymentResponse `json:"data"`
+}
+
+// PaymentResponse berisi referensi dan metode pembayaran dari wallet service, disimpan untuk struk
+type PaymentResponse struct {
+	Reference     string `json:"reference"`
+	PaymentMethod string `json:"paymentMethod"`
+}
+
 type TransactionResponse struct {
-	Id          int64                   `json:"id" db:"id"`
-	OrderStatus int8                    `json:"orderStatus" db:"order_status"`
-	TotalPrice  float64                 `json:"totalPrice" db:"total_price"`
-	OrderFor    string                  `json:"orderFor" db:"order_for"`
-	OrderBy     string                  `json:"orderBy"`
-	UserId      int64                   `json:"userId" db:"user_id"`
-	TableName   string                  `json:"tableName"`
-	CreatedAt   string                  `json:"createdAt" db:"created_at"`
-	UpdatedAt   string                  `json:"updatedAt" db:"updated_at"`
-	TableId     int64                   `json:"tableId" db:"table_id"`
-	Details     JSONBTransactionDetails `json:"details" db:"details"`
+	Id               int64                   `json:"id" db:"id"`
+	OrderStatus      int8                    `json:"orderStatus" db:"order_status"`
+	TotalPrice       float64                 `json:"totalPrice" db:"total_price"`
+	OrderFor         string                  `json:"orderFor" db:"order_for"`
+	PaymentMethod    string                  `json:"paymentMethod" db:"payment_method"`
+	PaymentReference string                  `json:"paymentReference" db:"payment_reference"`
+	OrderBy          string                  `json:"orderBy"`
+	UserId           int64                   `json:"userId" db:"user_id"`
+	TableName        string                  `json:"tableName"`
+	CreatedAt        string                  `json:"createdAt" db:"created_at"`
+	UpdatedAt        string                  `json:"updatedAt" db:"updated_at"`
+	TableId          int64                   `json:"tableId" db:"table_id"`
+	Details          JSONBTransactionDetails `json:"details" db:"details"`
+}
+
+// transactionExpand menentukan relasi yang dilengkapi saat enrichment
+type transactionExpand struct {
+	Menus bool
+	Table bool
+	User  bool
+}
+
+var expandAll = transactionExpand{Menus: true, Table: true, User: true}
+
+// TransactionStreamQuery adalah query export yang sudah divalidasi, dibentuk sebelum response dikirim
+type TransactionStreamQuery struct {
+	Query string
+	Args  map[string]interface{}
+}
+
+// transactionCursorRow menampung nilai kolom sort untuk membuat nextCursor
+type transactionCursorRow struct {
+	TransactionResponse
+	CursorValue sql.NullString `db:"cursor_value"`
 }
 
 type JSONBTransactionDetails []TransactionDetail
@@ -95,16 +132,18 @@ func (d *JSONBTransactionDetails) Scan(value interface{}) error {
 }
 
 type TransactionDetail struct {
-	MenuId      int     `json:"menuId" db:"menuId"`
-	Qty         int     `json:"qty" db:"qty"`
-	Price       float64 `json:"price" db:"price"`
-	Id          int     `json:"id" db:"id"`
-	Notes       string  `json:"notes" db:"notes"`
-	TotalPrice  float64 `json:"totalPrice" db:"totalPrice"`
-	Rating      *int8   `json:"rating" db:"rating"`
-	Description string  `json:"description" db:"description"`
-	MenuName    string  `json:"menuName"`
-	Photo       string  `json:"photo" db:"photo"`
+	MenuId       int      `json:"menuId" db:"menuId"`
+	Qty          int      `json:"qty" db:"qty"`
+	Price        float64  `json:"price" db:"price"`
+	Id           int      `json:"id" db:"id"`
+	Notes        string   `json:"notes" db:"notes"`
+	TotalPrice   float64  `json:"totalPrice" db:"totalPrice"`
+	Rating       *int8    `json:"rating" db:"rating"`
+	Review       string   `json:"review" db:"review"`
+	ReviewPhotos []string `json:"reviewPhotos" db:"reviewPhotos"`
+	Description  string   `json:"description" db:"description"`
+	MenuName     string   `json:"menuName"`
+	Photo        string   `json:"photo" db:"photo"`
 }
 
 type UpdateOrderStatusRequest struct {
@@ -113,19 +152,292 @@ type UpdateOrderStatusRequest struct {
 }
 
 type SetRatingMenuRequest struct {
-	Id        int   `json:"id" validate:"required"`
-	Rating    int   `json:"rating" validate:"required,min=1,max=5"`
-	UpdatedBy int64 `json:"updatedBy"`
+	Id        int                     `json:"id" form:"id" validate:"required"`
+	Rating    int                     `json:"rating" form:"rating" validate:"required,min=1,max=5"`
+	Review    *string                 `json:"review" form:"review" validate:"omitempty,max=1000"`
+	Photos    []*multipart.FileHeader `json:"-" form:"-"`
+	UpdatedBy int64                   `json:"updatedBy" form:"-"`
+}
+
+type RatingMenuEvent struct {
+	Id             int      `json:"id"`
+	Rating         int      `json:"rating"`
+	PreviousRating *int     `json:"previousRating,omitempty"`
+	Review         string   `json:"review"`
+	Photos         []string `json:"photos"`
+	UpdatedBy      int64    `json:"updatedBy"`
+}
+
+type RatingEligibility struct {
+	UserId       int64 `db:"user_id"`
+	OrderStatus  int8  `db:"order_status"`
+	WithinWindow bool  `db:"within_window"`
+}
+
+type RatingDetail struct {
+	Id           int          `db:"id"`
+	RefId        int          `db:"ref_id"`
+	MenuId       int          `db:"menu_id"`
+	Rating       *int         `db:"rating"`
+	Review       string       `db:"review"`
+	ReviewPhotos JSONBStrings `db:"review_photos"`
+	RatingEligibility
+}
+
+type JSONBStrings []string
+
+func (d *JSONBStrings) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to type assert value to []byte")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+type SetRatingServiceRequest struct {
+	Id                 int    `json:"id" validate:"required"`
+	SpeedRating        int    `json:"speedRating" validate:"required,min=1,max=5"`
+	FriendlinessRating int    `json:"friendlinessRating" validate:"required,min=1,max=5"`
+	Comment            string `json:"comment" validate:"max=1000"`
+	UpdatedBy          int64  `json:"updatedBy"`
+}
+
+type ServiceRatingDetail struct {
+	Id                 int  `db:"id"`
+	SpeedRating        *int `db:"speed_rating"`
+	FriendlinessRating *int `db:"friendliness_rating"`
+	RatingEligibility
+}
+
+type ServiceRatingEvent struct {
+	Id                         int    `json:"id"`
+	SpeedRating                int    `json:"speedRating"`
+	FriendlinessRating         int    `json:"friendlinessRating"`
+	PreviousSpeedRating        *int   `json:"previousSpeedRating,omitempty"`
+	PreviousFriendlinessRating *int   `json:"previousFriendlinessRating,omitempty"`
+	Comment                    string `json:"comment"`
+	UpdatedBy                  int64  `json:"updatedBy"`
+}
+
+type GetListServiceRatingsRequest struct {
+	StartDate string  `json:"startDate"`
+	EndDate   string  `json:"endDate"`
+	MinScore  float64 `json:"minScore" validate:"omitempty,min=1,max=5"`
+	MaxScore  float64 `json:"maxScore" validate:"omitempty,min=1,max=5,gtefield=MinScore"`
+	common.ParamsListRequest
+}
+
+type ServiceRatingResponse struct {
+	Id                 int64   `json:"id" db:"id"`
+	UserId             int64   `json:"userId" db:"user_id"`
+	OrderBy            string  `json:"orderBy"`
+	TableId            int64   `json:"tableId" db:"table_id"`
+	TotalPrice         float64 `json:"totalPrice" db:"total_price"`
+	SpeedRating        int     `json:"speedRating" db:"speed_rating"`
+	FriendlinessRating int     `json:"friendlinessRating" db:"friendliness_rating"`
+	Score              float64 `json:"score" db:"score"`
+	Comment            string  `json:"comment" db:"service_comment"`
+	RatedAt            string  `json:"ratedAt" db:"service_rated_at"`
+}
+
+// TransactionFilter adalah filter list transaksi yang sama untuk admin dan history customer.
+// MenuId, Rated, MinRating dan HasNotes dicocokkan ke detail transaksi
+type TransactionFilter struct {
+	StartDate string `json:"startDate" query:"startDate" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"endDate" query:"endDate" validate:"omitempty,datetime=2006-01-02"`
+	Status    *int8  `json:"status" query:"status" validate:"omitempty,min=0,max=2"`
+	TableId   int64  `json:"tableId" query:"tableId" validate:"omitempty,gt=0"`
+	MenuId    int    `json:"menuId" query:"menuId" validate:"omitempty,gt=0"`
+	Rated     *bool  `json:"rated" query:"rated"`
+	MinRating int    `json:"minRating" query:"minRating" validate:"omitempty,min=1,max=5"`
+	HasNotes  *bool  `json:"hasNotes" query:"hasNotes"`
 }
 
 type GetListTransactionsRequest struct {
-	StartDate string `json:"startDate"`
-	EndDate   string `json:"endDate"`
+	Format string `json:"format" validate:"omitempty,oneof=csv xlsx ndjson"`
+	TransactionFilter
 	common.ParamsListRequest
 }
 
+type SummaryReportRequest struct {
+	StartDate   string `json:"startDate" query:"startDate"`
+	EndDate     string `json:"endDate" query:"endDate"`
+	Granularity string `json:"granularity" query:"granularity" validate:"omitempty,oneof=hour day week month"`
+	Timezone    string `json:"timezone" query:"timezone" validate:"omitempty,timezone"`
+}
+
 type SummaryReport struct {
 	Total      float64 `json:"total" db:"total"`
 	TotalOrder int64   `json:"totalOrder" db:"total_order"`
 	CreatedAt  string  `json:"createdAt" db:"created_at"`
 }
+
+type RatingStatsRequest struct {
+	StartDate string `json:"startDate" query:"startDate"`
+	EndDate   string `json:"endDate" query:"endDate"`
+	MenuId    int    `json:"menuId" query:"menuId" validate:"omitempty,gt=0"`
+}
+
+type MenuRatingStats struct {
+	MenuId       int                `json:"menuId" db:"menu_id"`
+	MenuName     string             `json:"menuName"`
+	Photo        string             `json:"photo"`
+	Average      float64            `json:"average" db:"average"`
+	TotalRating  int64              `json:"totalRating" db:"total_rating"`
+	Distribution RatingDistribution `json:"distribution"`
+}
+
+type RatingDistribution struct {
+	One   int64 `json:"1" db:"rating_1"`
+	Two   int64 `json:"2" db:"rating_2"`
+	Three int64 `json:"3" db:"rating_3"`
+	Four  int64 `json:"4" db:"rating_4"`
+	Five  int64 `json:"5" db:"rating_5"`
+}
+
+type TopMenusReportRequest struct {
+	StartDate string `json:"startDate" query:"startDate"`
+	EndDate   string `json:"endDate" query:"endDate"`
+	SortBy    string `json:"sortBy" query:"sortBy" validate:"omitempty,oneof=qty revenue orders"`
+	Limit     int    `json:"limit" query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type TopMenuReport struct {
+	MenuId     int     `json:"menuId" db:"menu_id"`
+	MenuName   string  `json:"menuName"`
+	Photo      string  `json:"photo"`
+	TotalQty   int64   `json:"totalQty" db:"total_qty"`
+	Revenue    float64 `json:"revenue" db:"revenue"`
+	TotalOrder int64   `json:"totalOrder" db:"total_order"`
+	Share      float64 `json:"share" db:"share"`
+}
+
+type PeakHoursReportRequest struct {
+	StartDate string `json:"startDate" query:"startDate"`
+	EndDate   string `json:"endDate" query:"endDate"`
+	Timezone  string `json:"timezone" query:"timezone" validate:"omitempty,timezone"`
+}
+
+type PeakHoursDay struct {
+	Weekday int             `json:"weekday"`
+	Label   string          `json:"label"`
+	Hours   []PeakHoursCell `json:"hours"`
+}
+
+type PeakHoursCell struct {
+	Weekday        int      `json:"-" db:"weekday"`
+	Hour           int      `json:"hour" db:"hour"`
+	TotalOrder     int64    `json:"totalOrder" db:"total_order"`
+	Total          float64  `json:"total" db:"total"`
+	AvgPrepMinutes *float64 `json:"avgPrepMinutes" db:"avg_prep_minutes"`
+}
+
+type CohortReportRequest struct {
+	StartDate string `json:"startDate" query:"startDate"`
+	EndDate   string `json:"endDate" query:"endDate"`
+	Timezone  string `json:"timezone" query:"timezone" validate:"omitempty,timezone"`
+}
+
+type CohortReport struct {
+	Cohort            string          `json:"cohort" db:"cohort"`
+	Customers         int64           `json:"customers" db:"customers"`
+	RepeatCustomers   int64           `json:"repeatCustomers" db:"repeat_customers"`
+	RepeatRate        float64         `json:"repeatRate" db:"repeat_rate"`
+	TotalOrder        int64           `json:"totalOrder" db:"total_order"`
+	Total             float64         `json:"total" db:"total"`
+	AverageOrderValue float64         `json:"averageOrderValue" db:"average_order_value"`
+	Frequency         float64         `json:"frequency" db:"frequency"`
+	Retention         JSONBRetentions `json:"retention" db:"retention"`
+}
+
+type JSONBRetentions []CohortRetention
+
+func (d *JSONBRetentions) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to type assert value to []byte")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+type CohortRetention struct {
+	MonthOffset int64   `json:"monthOffset"`
+	Customers   int64   `json:"customers"`
+	Rate        float64 `json:"rate"`
+}
+
+type CreateDailyClosingRequest struct {
+	ClosingDate string `json:"closingDate" validate:"required,datetime=2006-01-02"`
+	Notes       string `json:"notes" validate:"max=500"`
+	ClosedBy    int64  `json:"closedBy"`
+}
+
+type DailyClosingListRequest struct {
+	StartDate string `json:"startDate" query:"startDate"`
+	EndDate   string `json:"endDate" query:"endDate"`
+}
+
+type DailyClosingTotals struct {
+	TotalUnfinished int64                    `json:"-" db:"total_unfinished"`
+	TotalOrder      int64                    `json:"totalOrder" db:"total_order"`
+	Gross           float64                  `json:"gross" db:"gross"`
+	ByPaymentMethod JSONBPaymentMethodTotals `json:"byPaymentMethod" db:"by_payment_method"`
+}
+
+type DailyClosing struct {
+	Id          int64  `json:"id" db:"id"`
+	ClosingDate string `json:"closingDate" db:"closing_date"`
+	DailyClosingTotals
+	Notes        string `json:"notes" db:"notes"`
+	ClosedAt     string `json:"closedAt" db:"closed_at"`
+	ClosedBy     int64  `json:"closedBy" db:"closed_by"`
+	ClosedByName string `json:"closedByName"`
+}
+
+type JSONBPaymentMethodTotals []PaymentMethodTotal
+
+func (d *JSONBPaymentMethodTotals) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to type assert value to []byte")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+type PaymentMethodTotal struct {
+	PaymentMethod string  `json:"paymentMethod"`
+	TotalOrder    int64   `json:"totalOrder"`
+	Total         float64 `json:"total"`
+}
+
+type SalesBreakdownReportRequest struct {
+	StartDate  string   `json:"startDate" query:"startDate"`
+	EndDate    string   `json:"endDate" query:"endDate"`
+	GroupBy    string   `json:"groupBy" query:"groupBy" validate:"required"`
+	Timezone   string   `json:"timezone" query:"timezone" validate:"omitempty,timezone"`
+	Dimensions []string `json:"-" query:"-"`
+}
+
+type SalesBreakdownReport struct {
+	Dimensions []SalesBreakdownDimension `json:"dimensions"`
+	Total      float64                   `json:"total"`
+	TotalOrder int64                     `json:"totalOrder"`
+}
+
+type SalesBreakdownDimension struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+	Label string `json:"label"`
+}
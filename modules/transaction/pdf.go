@@ -0,0 +1,171 @@
+package transaction
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"eka-dev.cloud/transaction-service/config"
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/go-pdf/fpdf"
+	"github.com/gofiber/fiber/v2/log"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const (
+	receiptWidth      = 80.0
+	receiptMargin     = 5.0
+	receiptLineHeight = 5.0
+)
+
+var pricePrinter = message.NewPrinter(language.Indonesian)
+
+func formatPrice(price float64) string {
+	return pricePrinter.Sprintf("Rp %.0f", price)
+}
+
+func formatDateTime(value string) string {
+	if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return parsed.Format("02 Jan 2006 15:04")
+	}
+	return value
+}
+
+// receiptMeasureHeight dipakai untuk render pertama yang hanya mengukur tinggi konten
+const receiptMeasureHeight = 1000.0
+
+// renderReceiptPDF menggambar konten dua kali: pertama di kertas tinggi untuk mengukur
+// tinggi konten (termasuk baris yang terbungkus), lalu di kertas dengan tinggi yang pas
+// seperti struk thermal
+func renderReceiptPDF(draw func(pdf *fpdf.Fpdf, tr func(string) string)) ([]byte, error) {
+	measure, tr := newReceiptPDF(receiptMeasureHeight)
+	draw(measure, tr)
+	height := float64(measure.PageNo()-1)*receiptMeasureHeight + measure.GetY() + receiptMargin
+
+	pdf, tr := newReceiptPDF(height)
+	draw(pdf, tr)
+	return outputPDF(pdf)
+}
+
+func newReceiptPDF(height float64) (*fpdf.Fpdf, func(string) string) {
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: receiptWidth, Ht: height},
+	})
+	pdf.SetMargins(receiptMargin, receiptMargin, receiptMargin)
+	pdf.SetAutoPageBreak(true, receiptMargin)
+	pdf.AddPage()
+
+	return pdf, pdf.UnicodeTranslatorFromDescriptor("")
+}
+
+func writeOutletHeader(pdf *fpdf.Fpdf, tr func(string) string) {
+	contentWidth := receiptWidth - 2*receiptMargin
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.MultiCell(contentWidth, receiptLineHeight+1, tr(config.Config.OutletName), "", "C", false)
+	pdf.SetFont("Helvetica", "", 8)
+	if config.Config.OutletAddress != "" {
+		pdf.MultiCell(contentWidth, receiptLineHeight-1, tr(config.Config.OutletAddress), "", "C", false)
+	}
+	if config.Config.OutletPhone != "" {
+		pdf.MultiCell(contentWidth, receiptLineHeight-1, tr(config.Config.OutletPhone), "", "C", false)
+	}
+	writeSeparator(pdf)
+}
+
+func writeSeparator(pdf *fpdf.Fpdf) {
+	pdf.Ln(1)
+	y := pdf.GetY()
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(receiptMargin, y, receiptWidth-receiptMargin, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.Ln(2)
+}
+
+func writeKeyValue(pdf *fpdf.Fpdf, tr func(string) string, key string, value string) {
+	contentWidth := receiptWidth - 2*receiptMargin
+	pdf.CellFormat(contentWidth*0.4, receiptLineHeight, tr(key), "", 0, "L", false, 0, "")
+	pdf.CellFormat(contentWidth*0.6, receiptLineHeight, tr(value), "", 1, "R", false, 0, "")
+}
+
+func outputPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		log.Error("Failed to generate pdf:", err)
+		return nil, response.InternalServerError("Failed to generate pdf", nil)
+	}
+	return buf.Bytes(), nil
+}
+
+func generateReceiptPDF(record *TransactionResponse) ([]byte, error) {
+	return renderReceiptPDF(func(pdf *fpdf.Fpdf, tr func(string) string) {
+		contentWidth := receiptWidth - 2*receiptMargin
+
+		writeOutletHeader(pdf, tr)
+
+		pdf.SetFont("Helvetica", "", 8)
+		if record.PaymentReference != "" {
+			writeKeyValue(pdf, tr, "Reference", record.PaymentReference)
+		}
+		writeKeyValue(pdf, tr, "Date", formatDateTime(record.CreatedAt))
+		writeKeyValue(pdf, tr, "Table", record.TableName)
+		writeKeyValue(pdf, tr, "Order For", record.OrderFor)
+		writeKeyValue(pdf, tr, "Customer", record.OrderBy)
+		writeKeyValue(pdf, tr, "Status", orderStatusLabels[int64(record.OrderStatus)])
+		writeSeparator(pdf)
+
+		for _, detail := range record.Details {
+			pdf.SetFont("Helvetica", "B", 8)
+			pdf.MultiCell(contentWidth, receiptLineHeight-1, tr(detail.MenuName), "", "L", false)
+			pdf.SetFont("Helvetica", "", 8)
+			writeKeyValue(pdf, tr, fmt.Sprintf("%d x %s", detail.Qty, formatPrice(detail.Price)), formatPrice(detail.TotalPrice))
+			if detail.Notes != "" {
+				pdf.SetFont("Helvetica", "I", 7)
+				pdf.MultiCell(contentWidth, receiptLineHeight-1, tr("Notes: "+detail.Notes), "", "L", false)
+			}
+		}
+		writeSeparator(pdf)
+
+		pdf.SetFont("Helvetica", "B", 9)
+		writeKeyValue(pdf, tr, "Total", formatPrice(record.TotalPrice))
+		pdf.SetFont("Helvetica", "", 8)
+		writeKeyValue(pdf, tr, "Payment", record.PaymentMethod)
+		writeSeparator(pdf)
+
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(contentWidth, receiptLineHeight, tr("Thank you for your order"), "", 1, "C", false, 0, "")
+	})
+}
+
+func generateDailyClosingPDF(record *DailyClosing) ([]byte, error) {
+	return renderReceiptPDF(func(pdf *fpdf.Fpdf, tr func(string) string) {
+		contentWidth := receiptWidth - 2*receiptMargin
+
+		writeOutletHeader(pdf, tr)
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(contentWidth, receiptLineHeight, tr("Z-REPORT"), "", 1, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		writeKeyValue(pdf, tr, "Date", record.ClosingDate)
+		writeKeyValue(pdf, tr, "Closed At", formatDateTime(record.ClosedAt))
+		writeKeyValue(pdf, tr, "Closed By", record.ClosedByName)
+		writeSeparator(pdf)
+
+		writeKeyValue(pdf, tr, "Orders", fmt.Sprintf("%d", record.TotalOrder))
+		pdf.SetFont("Helvetica", "B", 9)
+		writeKeyValue(pdf, tr, "Gross", formatPrice(record.Gross))
+		writeSeparator(pdf)
+
+		pdf.SetFont("Helvetica", "", 8)
+		for _, payment := range record.ByPaymentMethod {
+			writeKeyValue(pdf, tr, fmt.Sprintf("%s (%d)", payment.PaymentMethod, payment.TotalOrder), formatPrice(payment.Total))
+		}
+
+		if record.Notes != "" {
+			writeSeparator(pdf)
+			pdf.MultiCell(contentWidth, receiptLineHeight-1, tr("Notes: "+record.Notes), "", "L", false)
+		}
+	})
+}
@@ -2,7 +2,12 @@ package transaction
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"eka-dev.cloud/transaction-service/utils/common"
 	"eka-dev.cloud/transaction-service/utils/response"
@@ -14,14 +19,35 @@ type Repository interface {
 	// TODO: define repository methods
 	InsertThTransaction(tx *sqlx.Tx, transaction CreateTransactionRequest) (int, error)
 	InsertTdTransaction(tx *sqlx.Tx, transactionId int, createdBy int64, data Data) error
-	GetListTransactionsPagination(params common.ParamsListRequest, startDate string, endDate string) (*response.Pagination[[]TransactionResponse], error)
-	GetListTransactionsNoPagination(request common.ParamsListRequest, startDate string, endDate string) ([]TransactionResponse, error)
+	GetListTransactionsPagination(params common.ParamsListRequest, filter TransactionFilter) (*response.Pagination[[]TransactionResponse], error)
+	GetListTransactionsNoPagination(request common.ParamsListRequest, filter TransactionFilter) ([]TransactionResponse, error)
+	BuildStreamListTransactionsQuery(request common.ParamsListRequest, filter TransactionFilter) (*TransactionStreamQuery, error)
+	StreamListTransactions(query *TransactionStreamQuery, batchSize int, fn func([]TransactionResponse) error) error
+	GetListTransactionsCursor(params common.ParamsListRequest, filter TransactionFilter) (*response.CursorPagination[[]TransactionResponse], error)
+	GetListTransactionsByUserIdCursor(params common.ParamsListRequest, filter TransactionFilter, userId int64) (*response.CursorPagination[[]TransactionResponse], error)
 	GetOneTransaction(id int) (*TransactionResponse, error)
-	GetListTransactionsByUserId(params common.ParamsListRequest, userId int64) (*response.Pagination[[]TransactionResponse], error)
+	GetListTransactionsByUserId(params common.ParamsListRequest, filter TransactionFilter, userId int64) (*response.Pagination[[]TransactionResponse], error)
 	GetOneTransactionByUserId(id int, userId int64) (*TransactionResponse, error)
 	UpdateOrderStatus(tx *sqlx.Tx, id int, updatedBy int64) error
-	SetRatingMenu(tx *sqlx.Tx, id int, rating int, updatedBy int64) (int, error)
-	SummaryReportTransactions(startDate string, endDate string) ([]SummaryReport, error)
+	GetRatingDetail(tx *sqlx.Tx, id int, windowDays int) (*RatingDetail, error)
+	SetRatingMenu(tx *sqlx.Tx, id int, rating int, review *string, updatedBy int64) error
+	SetRatingPhotos(tx *sqlx.Tx, id int, photos []string) error
+	GetServiceRatingDetail(tx *sqlx.Tx, id int, windowDays int) (*ServiceRatingDetail, error)
+	SetRatingService(tx *sqlx.Tx, request SetRatingServiceRequest) error
+	GetListServiceRatings(request GetListServiceRatingsRequest) (*response.Pagination[[]ServiceRatingResponse], error)
+	SummaryReportTransactions(request SummaryReportRequest) ([]SummaryReport, error)
+	GetRatingStats(request RatingStatsRequest) ([]MenuRatingStats, error)
+	TopMenusReport(request TopMenusReportRequest) ([]TopMenuReport, error)
+	SalesBreakdownReport(request SalesBreakdownReportRequest) ([]SalesBreakdownReport, error)
+	PeakHoursReport(request PeakHoursReportRequest) ([]PeakHoursCell, error)
+	CohortReport(request CohortReportRequest) ([]CohortReport, error)
+	IsTodayClosed(tx *sqlx.Tx) (bool, error)
+	IsTransactionDayClosed(tx *sqlx.Tx, id int) (bool, error)
+	LockClosingDate(tx *sqlx.Tx, closingDate string) (bool, error)
+	GetDailyClosingTotals(tx *sqlx.Tx, closingDate string) (*DailyClosingTotals, error)
+	InsertDailyClosing(tx *sqlx.Tx, request CreateDailyClosingRequest, totals *DailyClosingTotals) error
+	GetListDailyClosings(request DailyClosingListRequest) ([]DailyClosing, error)
+	GetOneDailyClosing(id int) (*DailyClosing, error)
 }
 
 type transactionRepository struct {
@@ -34,9 +60,10 @@ func NewTransactionRepository(db *sqlx.DB) Repository {
 
 func (r *transactionRepository) InsertThTransaction(tx *sqlx.Tx, transaction CreateTransactionRequest) (int, error) {
 	var id int
-	query := `INSERT INTO th_user_checkouts (user_id, table_id, order_for, total_price, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	query := `INSERT INTO th_user_checkouts (user_id, table_id, order_for, total_price, payment_method, payment_reference, created_by) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7) RETURNING id`
 
-	err := tx.QueryRow(query, transaction.CreatedBy, transaction.TableId, transaction.OrderFor, transaction.Total, transaction.CreatedBy).Scan(&id)
+	err := tx.QueryRow(query, transaction.CreatedBy, transaction.TableId, transaction.OrderFor, transaction.Total,
+		transaction.Payment.PaymentMethod, transaction.Payment.Reference, transaction.CreatedBy).Scan(&id)
 	if err != nil {
 		log.Error("Failed to insert transaction:", err)
 		return 0, response.InternalServerError("Failed to insert transaction", nil)
@@ -45,9 +72,9 @@ func (r *transactionRepository) InsertThTransaction(tx *sqlx.Tx, transaction Cre
 }
 
 func (r *transactionRepository) InsertTdTransaction(tx *sqlx.Tx, transactionId int, createdBy int64, data Data) error {
-	query := `INSERT INTO td_user_checkouts (ref_id, menu_id, qty, price, total_price, notes, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO td_user_checkouts (ref_id, menu_id, qty, price, total_price, notes, menu_name, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
-	_, err := tx.Exec(query, transactionId, data.MenuID, data.Qty, data.Price, data.Total, data.Notes, createdBy)
+	_, err := tx.Exec(query, transactionId, data.MenuID, data.Qty, data.Price, data.Total, data.Notes, data.MenuName, createdBy)
 	if err != nil {
 		log.Error("Failed to insert transaction detail:", err)
 		return response.InternalServerError("Failed to insert transaction detail", nil)
@@ -55,23 +82,48 @@ func (r *transactionRepository) InsertTdTransaction(tx *sqlx.Tx, transactionId i
 	return nil
 }
 
-func (r *transactionRepository) GetListTransactionsPagination(params common.ParamsListRequest, startDate string, endDate string) (*response.Pagination[[]TransactionResponse], error) {
-	var record = make([]TransactionResponse, 0)
+func (r *transactionRepository) GetListTransactionsPagination(params common.ParamsListRequest, filter TransactionFilter) (*response.Pagination[[]TransactionResponse], error) {
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
+
+	query, args := applyTransactionFilter(baseQuery, filter)
+	queryCount, _ := applyTransactionFilter("SELECT COUNT(id) FROM th_user_checkouts t ", filter)
+	query, textQuery := applyTextSearch(query, &params)
+	queryCount, _ = applyTextSearch(queryCount, &params)
+	args["text_query"] = textQuery
+
+	return common.QueryPagination[TransactionResponse](r.db, params, common.PaginationQuery{
+		Name:             "transaction",
+		Query:            query,
+		CountQuery:       queryCount,
+		GroupBy:          " GROUP BY t.id ",
+		Args:             args,
+		MappingFieldType: &mappingFiedType,
+	})
+}
 
-	common.BuildMappingField(params, &mappingFieds)
+func (r *transactionRepository) GetListTransactionsNoPagination(request common.ParamsListRequest, filter TransactionFilter) ([]TransactionResponse, error) {
+	var record = make([]TransactionResponse, 0)
 
-	query := baseQuery
-	if startDate != "" && endDate != "" {
-		query += " WHERE CAST(t.created_at AS DATE) BETWEEN :start_date AND :end_date "
+	if err := common.BuildMappingField(request, &mappingFieds); err != nil {
+		return nil, err
 	}
 
-	finalQuery, args := common.BuildFilterQuery(query, params, &mappingFiedType, " GROUP BY t.id ")
+	query, filterArgs := applyTransactionFilter(baseQuery, filter)
+	query, textQuery := applyTextSearch(query, &request)
 
-	args["start_date"] = startDate
-	args["end_date"] = endDate
+	finalQuery, args, err := common.BuildFilterQuery(query, request, &mappingFiedType, " GROUP BY t.id ")
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := r.db.NamedQuery(finalQuery, args)
+	for key, value := range filterArgs {
+		args[key] = value
+	}
+	args["text_query"] = textQuery
 
+	rows, err := r.db.NamedQuery(finalQuery, args)
 	if err != nil {
 		log.Error("Failed to get list transaction:", err)
 		return nil, response.InternalServerError("Failed to get list transaction", nil)
@@ -93,71 +145,39 @@ func (r *transactionRepository) GetListTransactionsPagination(params common.Para
 		record = append(record, transaction)
 	}
 
-	var totalData int
-
-	queryCount := "SELECT COUNT(id) FROM th_user_checkouts t "
+	return record, nil
+}
 
-	if startDate != "" && endDate != "" {
-		queryCount += " WHERE CAST(t.created_at AS DATE) BETWEEN :start_date AND :end_date "
+// BuildStreamListTransactionsQuery memvalidasi param dan membentuk query export. Dipanggil sebelum
+// status response dikirim supaya param yang salah tetap dibalas 400
+func (r *transactionRepository) BuildStreamListTransactionsQuery(request common.ParamsListRequest, filter TransactionFilter) (*TransactionStreamQuery, error) {
+	if err := common.BuildMappingField(request, &mappingFieds); err != nil {
+		return nil, err
 	}
 
-	countFinalQuery, countArgs := common.BuildCountQuery(queryCount, params, &mappingFiedType)
-
-	countArgs["start_date"] = startDate
-	countArgs["end_date"] = endDate
+	query, filterArgs := applyTransactionFilter(baseQuery, filter)
 
-	countStmt, err := r.db.PrepareNamed(countFinalQuery)
+	request.NoPaginate = true
+	query, textQuery := applyTextSearch(query, &request)
 
+	finalQuery, args, err := common.BuildFilterQuery(query, request, &mappingFiedType, " GROUP BY t.id ")
 	if err != nil {
-		log.Error("Failed to prepare count query:", err)
-		return nil, response.InternalServerError("Failed to get list transaction count", nil)
+		return nil, err
 	}
 
-	defer func(countStmt *sqlx.NamedStmt) {
-		err := countStmt.Close()
-		if err != nil {
-			log.Error("failed to close count statement:", err)
-			return
-		}
-	}(countStmt)
-
-	if err := countStmt.Get(&totalData, countArgs); err != nil {
-		log.Error("Failed to get total data:", err)
-		return nil, response.InternalServerError("Failed to get list transaction count", nil)
-	}
-
-	pagination := response.Pagination[[]TransactionResponse]{
-		TotalData:   totalData,
-		Data:        record,
-		CurrentPage: params.Page,
-		PageSize:    params.Size,
-		TotalPages:  (totalData + params.Size - 1) / params.Size,
-		LastPage:    params.Page >= (totalData+params.Size-1)/params.Size,
+	for key, value := range filterArgs {
+		args[key] = value
 	}
+	args["text_query"] = textQuery
 
-	return &pagination, nil
+	return &TransactionStreamQuery{Query: finalQuery, Args: args}, nil
 }
 
-func (r *transactionRepository) GetListTransactionsNoPagination(request common.ParamsListRequest, startDate string, endDate string) ([]TransactionResponse, error) {
-	var record = make([]TransactionResponse, 0)
-
-	common.BuildMappingField(request, &mappingFieds)
-
-	query := baseQuery
-
-	if startDate != "" && endDate != "" {
-		query += " WHERE CAST(t.created_at AS DATE) BETWEEN :start_date AND :end_date "
-	}
-
-	finalQuery, args := common.BuildFilterQuery(query, request, &mappingFiedType, " GROUP BY t.id ")
-
-	args["start_date"] = startDate
-	args["end_date"] = endDate
-
-	rows, err := r.db.NamedQuery(finalQuery, args)
+func (r *transactionRepository) StreamListTransactions(query *TransactionStreamQuery, batchSize int, fn func([]TransactionResponse) error) error {
+	rows, err := r.db.NamedQuery(query.Query, query.Args)
 	if err != nil {
 		log.Error("Failed to get list transaction:", err)
-		return nil, response.InternalServerError("Failed to get list transaction", nil)
+		return response.InternalServerError("Failed to get list transaction", nil)
 	}
 	defer func(rows *sqlx.Rows) {
 		err := rows.Close()
@@ -167,45 +187,81 @@ func (r *transactionRepository) GetListTransactionsNoPagination(request common.P
 		}
 	}(rows)
 
+	// row dibaca per batch supaya memory tetap kecil untuk range tanggal yang panjang
+	batch := make([]TransactionResponse, 0, batchSize)
 	for rows.Next() {
 		var transaction TransactionResponse
 		if err := rows.StructScan(&transaction); err != nil {
 			log.Error("Failed to scan transaction:", err)
-			return nil, response.InternalServerError("Failed to scan transaction", nil)
+			return response.InternalServerError("Failed to scan transaction", nil)
+		}
+		batch = append(batch, transaction)
+
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]TransactionResponse, 0, batchSize)
 		}
-		record = append(record, transaction)
 	}
 
-	return record, nil
+	if err := rows.Err(); err != nil {
+		log.Error("Failed to iterate transaction:", err)
+		return response.InternalServerError("Failed to get list transaction", nil)
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+
+	return nil
 }
 
-func (r *transactionRepository) GetOneTransaction(id int) (*TransactionResponse, error) {
-	var record TransactionResponse
-	query := baseQuery + " WHERE t.id = $1 GROUP BY t.id "
+func (r *transactionRepository) GetListTransactionsCursor(params common.ParamsListRequest, filter TransactionFilter) (*response.CursorPagination[[]TransactionResponse], error) {
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
 
-	err := r.db.Get(&record, query, id)
+	query, filterArgs := applyTransactionFilter(baseQuery, filter)
+	query, textQuery := applyTextSearch(query, &params)
+
+	finalQuery, args, err := common.BuildCursorQuery(query, params, &mappingFiedType, "t.id", " GROUP BY t.id ")
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, response.NotFound("Transaction not found", nil)
-		}
-		log.Error("Failed to get transaction by ID:", err)
-		return nil, response.InternalServerError("Failed to get transaction by ID", nil)
+		return nil, err
 	}
 
-	return &record, nil
+	for key, value := range filterArgs {
+		args[key] = value
+	}
+	args["text_query"] = textQuery
+
+	return r.queryTransactionsCursor(finalQuery, args, params)
 }
 
-func (r *transactionRepository) GetListTransactionsByUserId(params common.ParamsListRequest, userId int64) (*response.Pagination[[]TransactionResponse], error) {
-	var record = make([]TransactionResponse, 0)
+func (r *transactionRepository) GetListTransactionsByUserIdCursor(params common.ParamsListRequest, filter TransactionFilter, userId int64) (*response.CursorPagination[[]TransactionResponse], error) {
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
 
-	common.BuildMappingField(params, &mappingFieds)
+	query, filterArgs := applyTransactionFilter(baseQuery+" WHERE t.user_id = :user_id ", filter)
+	query, textQuery := applyTextSearch(query, &params)
 
-	finalQuery, args := common.BuildFilterQuery(baseQuery+" WHERE t.user_id = :user_id ", params, &mappingFiedType, " GROUP BY t.id ")
+	finalQuery, args, err := common.BuildCursorQuery(query, params, &mappingFiedType, "t.id", " GROUP BY t.id ")
+	if err != nil {
+		return nil, err
+	}
 
+	for key, value := range filterArgs {
+		args[key] = value
+	}
 	args["user_id"] = userId
+	args["text_query"] = textQuery
 
-	rows, err := r.db.NamedQuery(finalQuery, args)
+	return r.queryTransactionsCursor(finalQuery, args, params)
+}
 
+func (r *transactionRepository) queryTransactionsCursor(query string, args map[string]interface{}, params common.ParamsListRequest) (*response.CursorPagination[[]TransactionResponse], error) {
+	rows, err := r.db.NamedQuery(query, args)
 	if err != nil {
 		log.Error("Failed to get list transaction:", err)
 		return nil, response.InternalServerError("Failed to get list transaction", nil)
@@ -218,51 +274,78 @@ func (r *transactionRepository) GetListTransactionsByUserId(params common.Params
 		}
 	}(rows)
 
+	var records = make([]transactionCursorRow, 0, params.Size+1)
 	for rows.Next() {
-		var transaction TransactionResponse
+		var transaction transactionCursorRow
 		if err := rows.StructScan(&transaction); err != nil {
 			log.Error("Failed to scan transaction:", err)
 			return nil, response.InternalServerError("Failed to scan transaction", nil)
 		}
-		record = append(record, transaction)
+		records = append(records, transaction)
 	}
 
-	var totalData int
-	countFinalQuery, countArgs := common.BuildCountQuery("SELECT COUNT(id) FROM th_user_checkouts WHERE user_id = :user_id ", params, &mappingFiedType)
-
-	countArgs["user_id"] = userId
-
-	countStmt, err := r.db.PrepareNamed(countFinalQuery)
+	// query mengambil size+1 row, row lebihnya hanya penanda masih ada halaman berikutnya
+	hasMore := len(records) > params.Size
+	if hasMore {
+		records = records[:params.Size]
+	}
 
-	if err != nil {
-		log.Error("Failed to prepare count query:", err)
-		return nil, response.InternalServerError("Failed to get list transaction count", nil)
+	pagination := response.CursorPagination[[]TransactionResponse]{
+		Data:     make([]TransactionResponse, 0, len(records)),
+		PageSize: params.Size,
+		HasMore:  hasMore,
+	}
+	for _, record := range records {
+		pagination.Data = append(pagination.Data, record.TransactionResponse)
 	}
 
-	defer func(countStmt *sqlx.NamedStmt) {
-		err := countStmt.Close()
+	if hasMore {
+		last := records[len(records)-1]
+		pagination.NextCursor, err = common.NextCursor(params, "t.id", last.CursorValue, last.Id)
 		if err != nil {
-			log.Error("failed to close count statement:", err)
-			return
+			return nil, err
 		}
-	}(countStmt)
-
-	if err := countStmt.Get(&totalData, countArgs); err != nil {
-		log.Error("Failed to get total data:", err)
-		return nil, response.InternalServerError("Failed to get list transaction count", nil)
 	}
 
-	pagination := response.Pagination[[]TransactionResponse]{
-		TotalData:   totalData,
-		Data:        record,
-		CurrentPage: params.Page,
-		PageSize:    params.Size,
-		TotalPages:  (totalData + params.Size - 1) / params.Size,
-		LastPage:    params.Page >= (totalData+params.Size-1)/params.Size,
+	return &pagination, nil
+}
+
+func (r *transactionRepository) GetOneTransaction(id int) (*TransactionResponse, error) {
+	var record TransactionResponse
+	query := baseQuery + " WHERE t.id = $1 GROUP BY t.id "
+
+	err := r.db.Get(&record, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, response.NotFound("Transaction not found", nil)
+		}
+		log.Error("Failed to get transaction by ID:", err)
+		return nil, response.InternalServerError("Failed to get transaction by ID", nil)
 	}
 
-	return &pagination, nil
+	return &record, nil
+}
+
+func (r *transactionRepository) GetListTransactionsByUserId(params common.ParamsListRequest, filter TransactionFilter, userId int64) (*response.Pagination[[]TransactionResponse], error) {
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
 
+	query, args := applyTransactionFilter(baseQuery+" WHERE t.user_id = :user_id ", filter)
+	queryCount, _ := applyTransactionFilter("SELECT COUNT(t.id) FROM th_user_checkouts t WHERE t.user_id = :user_id ", filter)
+	query, textQuery := applyTextSearch(query, &params)
+	queryCount, _ = applyTextSearch(queryCount, &params)
+	args["user_id"] = userId
+	args["text_query"] = textQuery
+
+	return common.QueryPagination[TransactionResponse](r.db, params, common.PaginationQuery{
+		Name:             "transaction",
+		Query:            query,
+		CountQuery:       queryCount,
+		GroupBy:          " GROUP BY t.id ",
+		Args:             args,
+		MappingFieldType: &mappingFiedType,
+	})
 }
 
 func (r *transactionRepository) GetOneTransactionByUserId(id int, userId int64) (*TransactionResponse, error) {
@@ -282,9 +365,11 @@ func (r *transactionRepository) GetOneTransactionByUserId(id int, userId int64)
 }
 
 func (r *transactionRepository) UpdateOrderStatus(tx *sqlx.Tx, id int, updatedBy int64) error {
-	query := `UPDATE th_user_checkouts SET order_status = order_status +1, updated_by = $1 WHERE id = $2 AND order_status  < 2`
+	query := `UPDATE th_user_checkouts SET order_status = order_status +1, updated_by = $1, updated_at = CURRENT_TIMESTAMP,
+		completed_at = CASE WHEN order_status + 1 = $3 THEN CURRENT_TIMESTAMP ELSE completed_at END
+		WHERE id = $2 AND order_status < $3`
 
-	result, err := tx.Exec(query, updatedBy, id)
+	result, err := tx.Exec(query, updatedBy, id, orderStatusCompleted)
 
 	if err != nil {
 		log.Error("Failed to update order status:", err)
@@ -300,32 +385,267 @@ func (r *transactionRepository) UpdateOrderStatus(tx *sqlx.Tx, id int, updatedBy
 	return nil
 }
 
-func (r *transactionRepository) SetRatingMenu(tx *sqlx.Tx, id int, rating int, updatedBy int64) (int, error) {
-	query := `UPDATE td_user_checkouts SET rating = $1, updated_by = $2 WHERE id = $3 AND rating IS NULL RETURNING menu_id`
+func (r *transactionRepository) IsTodayClosed(tx *sqlx.Tx) (bool, error) {
+	lockQuery := fmt.Sprintf(`SELECT pg_advisory_xact_lock_shared(%s)`, fmt.Sprintf(dailyClosingLockKey, "CURRENT_DATE"))
+	_, err := tx.Exec(lockQuery)
+	if err != nil {
+		log.Error("Failed to lock daily closing:", err)
+		return false, response.InternalServerError("Failed to check daily closing", nil)
+	}
+
+	var closed bool
+	query := `SELECT EXISTS (SELECT 1 FROM th_daily_closings WHERE closing_date = CURRENT_DATE)`
+
+	err = tx.Get(&closed, query)
+	if err != nil {
+		log.Error("Failed to check daily closing:", err)
+		return false, response.InternalServerError("Failed to check daily closing", nil)
+	}
+
+	return closed, nil
+}
+
+func (r *transactionRepository) IsTransactionDayClosed(tx *sqlx.Tx, id int) (bool, error) {
+	lockQuery := fmt.Sprintf(`SELECT pg_advisory_xact_lock_shared(%s) FROM th_user_checkouts WHERE id = $1`,
+		fmt.Sprintf(dailyClosingLockKey, "CAST(created_at AS DATE)"))
+	_, err := tx.Exec(lockQuery, id)
+	if err != nil {
+		log.Error("Failed to lock daily closing:", err)
+		return false, response.InternalServerError("Failed to check daily closing", nil)
+	}
+
+	var closed bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM th_daily_closings c
+		JOIN th_user_checkouts t ON c.closing_date = CAST(t.created_at AS DATE)
+		WHERE t.id = $1)`
+
+	err = tx.Get(&closed, query, id)
+	if err != nil {
+		log.Error("Failed to check daily closing:", err)
+		return false, response.InternalServerError("Failed to check daily closing", nil)
+	}
+
+	return closed, nil
+}
+
+// LockClosingDate mengambil lock exclusive untuk tanggal closing dan mengembalikan true
+// kalau tanggal tersebut masih di masa depan menurut tanggal database
+func (r *transactionRepository) LockClosingDate(tx *sqlx.Tx, closingDate string) (bool, error) {
+	lockQuery := fmt.Sprintf(`SELECT pg_advisory_xact_lock(%s)`, fmt.Sprintf(dailyClosingLockKey, "CAST($1 AS DATE)"))
+	_, err := tx.Exec(lockQuery, closingDate)
+	if err != nil {
+		log.Error("Failed to lock daily closing:", err)
+		return false, response.InternalServerError("Failed to close day", nil)
+	}
+
+	var future bool
+	err = tx.Get(&future, `SELECT CAST($1 AS DATE) > CURRENT_DATE`, closingDate)
+	if err != nil {
+		log.Error("Failed to check closing date:", err)
+		return false, response.InternalServerError("Failed to close day", nil)
+	}
+
+	return future, nil
+}
+
+func (r *transactionRepository) GetDailyClosingTotals(tx *sqlx.Tx, closingDate string) (*DailyClosingTotals, error) {
+	var totals DailyClosingTotals
+	err := tx.Get(&totals, dailyClosingTotalsQuery, closingDate, orderStatusCompleted)
+	if err != nil {
+		log.Error("Failed to get daily closing totals:", err)
+		return nil, response.InternalServerError("Failed to close day", nil)
+	}
+
+	return &totals, nil
+}
+
+func (r *transactionRepository) InsertDailyClosing(tx *sqlx.Tx, request CreateDailyClosingRequest, totals *DailyClosingTotals) error {
+	byPaymentMethod, err := json.Marshal(totals.ByPaymentMethod)
+	if err != nil {
+		log.Error("Failed to marshal payment method totals:", err)
+		return response.InternalServerError("Failed to close day", nil)
+	}
+
+	var id int
+	query := `INSERT INTO th_daily_closings (closing_date, total_order, gross, by_payment_method, notes, closed_by)
+		VALUES (CAST($1 AS DATE), $2, $3, CAST($4 AS JSONB), NULLIF($5, ''), $6)
+		ON CONFLICT (closing_date) DO NOTHING RETURNING id`
+
+	err = tx.QueryRow(query, request.ClosingDate, totals.TotalOrder, totals.Gross, string(byPaymentMethod), request.Notes,
+		request.ClosedBy).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response.BadRequest("Day already closed", nil)
+		}
+		log.Error("Failed to insert daily closing:", err)
+		return response.InternalServerError("Failed to close day", nil)
+	}
+
+	return nil
+}
+
+func (r *transactionRepository) GetListDailyClosings(request DailyClosingListRequest) ([]DailyClosing, error) {
+	var records = make([]DailyClosing, 0)
+	query := dailyClosingQuery + ` WHERE c.closing_date BETWEEN CAST($1 AS DATE) AND CAST($2 AS DATE) ORDER BY c.closing_date DESC`
+
+	err := r.db.Select(&records, query, request.StartDate, request.EndDate)
+	if err != nil {
+		log.Error("Failed to get daily closings:", err)
+		return nil, response.InternalServerError("Failed to get daily closings", nil)
+	}
+
+	return records, nil
+}
+
+func (r *transactionRepository) GetOneDailyClosing(id int) (*DailyClosing, error) {
+	var record DailyClosing
+	query := dailyClosingQuery + ` WHERE c.id = $1`
 
-	var menuId int
+	err := r.db.Get(&record, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, response.NotFound("Daily closing not found", nil)
+		}
+		log.Error("Failed to get daily closing by ID:", err)
+		return nil, response.InternalServerError("Failed to get daily closing by ID", nil)
+	}
 
-	err := tx.QueryRow(query, rating, updatedBy, id).Scan(&menuId)
+	return &record, nil
+}
+
+func (r *transactionRepository) GetRatingDetail(tx *sqlx.Tx, id int, windowDays int) (*RatingDetail, error) {
+	var record RatingDetail
+	query := `SELECT td.id, td.ref_id, td.menu_id, td.rating, COALESCE(td.review, '') AS review, td.review_photos, t.user_id, t.order_status,
+		COALESCE(t.completed_at + ($2 * INTERVAL '1 day') >= LOCALTIMESTAMP, false) AS within_window
+		FROM td_user_checkouts td
+		JOIN th_user_checkouts t ON t.id = td.ref_id
+		WHERE td.id = $1 FOR UPDATE OF td`
 
+	err := tx.Get(&record, query, id, windowDays)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, response.BadRequest("No rows were updated, possibly due to invalid ID or rating already set", nil)
+			return nil, response.NotFound("Transaction detail not found", nil)
 		}
+		log.Error("Failed to get transaction detail:", err)
+		return nil, response.InternalServerError("Failed to get transaction detail", nil)
+	}
+
+	return &record, nil
+}
+
+func (r *transactionRepository) SetRatingMenu(tx *sqlx.Tx, id int, rating int, review *string, updatedBy int64) error {
+	// review nil berarti field tidak dikirim, review lama tetap dipakai
+	query := `UPDATE td_user_checkouts SET rating = $1, review = CASE WHEN CAST($2 AS TEXT) IS NULL THEN review ELSE NULLIF($2, '') END, updated_by = $3, updated_at = CURRENT_TIMESTAMP, rated_at = CURRENT_TIMESTAMP WHERE id = $4`
+
+	result, err := tx.Exec(query, rating, review, updatedBy, id)
+	if err != nil {
 		log.Error("Failed to set rating:", err)
-		return 0, response.InternalServerError("Failed to set rating", nil)
+		return response.InternalServerError("Failed to set rating", nil)
 	}
 
-	return menuId, nil
+	return validateAffectedRows(result, "No rows were updated, possibly due to invalid ID")
 }
 
-func (r *transactionRepository) SummaryReportTransactions(startDate string, endDate string) ([]SummaryReport, error) {
-	var summary = make([]SummaryReport, 0)
-	query := `SELECT
-		SUM(t.total_price) AS total,  CAST(t.created_at AS DATE), COUNT(t.id) AS total_order				
+func (r *transactionRepository) SetRatingPhotos(tx *sqlx.Tx, id int, photos []string) error {
+	photosJson, err := json.Marshal(photos)
+	if err != nil {
+		log.Error("Failed to marshal rating photos:", err)
+		return response.InternalServerError("Failed to set rating photos", nil)
+	}
+
+	query := `UPDATE td_user_checkouts SET review_photos = $1 WHERE id = $2`
+
+	_, err = tx.Exec(query, photosJson, id)
+	if err != nil {
+		log.Error("Failed to set rating photos:", err)
+		return response.InternalServerError("Failed to set rating photos", nil)
+	}
+
+	return nil
+}
+
+func (r *transactionRepository) GetServiceRatingDetail(tx *sqlx.Tx, id int, windowDays int) (*ServiceRatingDetail, error) {
+	var record ServiceRatingDetail
+	query := `SELECT t.id, t.speed_rating, t.friendliness_rating, t.user_id, t.order_status,
+		COALESCE(t.completed_at + ($2 * INTERVAL '1 day') >= LOCALTIMESTAMP, false) AS within_window
 		FROM th_user_checkouts t
-		WHERE CAST(t.created_at AS DATE) BETWEEN $1 AND $2 group by CAST(t.created_at AS DATE)`
+		WHERE t.id = $1 FOR UPDATE`
+
+	err := tx.Get(&record, query, id, windowDays)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, response.NotFound("Transaction not found", nil)
+		}
+		log.Error("Failed to get transaction:", err)
+		return nil, response.InternalServerError("Failed to get transaction", nil)
+	}
+
+	return &record, nil
+}
+
+func (r *transactionRepository) SetRatingService(tx *sqlx.Tx, request SetRatingServiceRequest) error {
+	query := `UPDATE th_user_checkouts SET speed_rating = $1, friendliness_rating = $2, service_comment = NULLIF($3, ''),
+		service_rated_at = CURRENT_TIMESTAMP WHERE id = $4`
 
-	rows, err := r.db.Queryx(query, startDate, endDate)
+	result, err := tx.Exec(query, request.SpeedRating, request.FriendlinessRating, request.Comment, request.Id)
+	if err != nil {
+		log.Error("Failed to set service rating:", err)
+		return response.InternalServerError("Failed to set service rating", nil)
+	}
+
+	return validateAffectedRows(result, "No rows were updated, possibly due to invalid ID")
+}
+
+func (r *transactionRepository) GetListServiceRatings(request GetListServiceRatingsRequest) (*response.Pagination[[]ServiceRatingResponse], error) {
+	params := request.ParamsListRequest
+
+	if err := common.BuildMappingField(params, &mappingFieds); err != nil {
+		return nil, err
+	}
+
+	filter := ""
+	if request.StartDate != "" && request.EndDate != "" {
+		filter += " AND CAST(t.service_rated_at AS DATE) BETWEEN :start_date AND :end_date "
+	}
+	if request.MinScore > 0 {
+		filter += " AND (t.speed_rating + t.friendliness_rating) / 2.0 >= :min_score "
+	}
+	if request.MaxScore > 0 {
+		filter += " AND (t.speed_rating + t.friendliness_rating) / 2.0 <= :max_score "
+	}
+
+	return common.QueryPagination[ServiceRatingResponse](r.db, params, common.PaginationQuery{
+		Name:       "service rating",
+		Query:      serviceRatingQuery + filter,
+		CountQuery: "SELECT COUNT(id) FROM th_user_checkouts t WHERE t.service_rated_at IS NOT NULL " + filter,
+		Args: map[string]interface{}{
+			"start_date": request.StartDate,
+			"end_date":   request.EndDate,
+			"min_score":  request.MinScore,
+			"max_score":  request.MaxScore,
+		},
+		MappingFieldType: &mappingFiedType,
+	})
+}
+
+func (r *transactionRepository) SummaryReportTransactions(request SummaryReportRequest) ([]SummaryReport, error) {
+	var summary = make([]SummaryReport, 0)
+
+	args := map[string]interface{}{
+		"start_date":  request.StartDate,
+		"end_date":    request.EndDate,
+		"granularity": request.Granularity,
+		"timezone":    request.Timezone,
+	}
+
+	// agregat harian hanya bisa dipakai kalau tidak perlu digeser ke timezone lain
+	totals := summaryReportHourlyTotals
+	if request.Timezone == "" && request.Granularity != granularityHour {
+		totals = summaryReportDailyTotals
+	}
+
+	rows, err := r.db.NamedQuery(fmt.Sprintf(summaryReportBucketsQuery, totals), args)
 	if err != nil {
 		log.Error("Failed to get summary report:", err)
 		return nil, response.InternalServerError("Failed to get summary report", nil)
@@ -350,6 +670,228 @@ func (r *transactionRepository) SummaryReportTransactions(startDate string, endD
 	return summary, nil
 }
 
+func (r *transactionRepository) GetRatingStats(request RatingStatsRequest) ([]MenuRatingStats, error) {
+	var stats = make([]MenuRatingStats, 0)
+
+	query := ratingStatsQuery
+	if request.MenuId > 0 {
+		query += " AND td.menu_id = :menu_id "
+	}
+	query += " GROUP BY td.menu_id ORDER BY average DESC, total_rating DESC "
+
+	args := map[string]interface{}{
+		"start_date": request.StartDate,
+		"end_date":   request.EndDate,
+		"menu_id":    request.MenuId,
+	}
+
+	rows, err := r.db.NamedQuery(query, args)
+	if err != nil {
+		log.Error("Failed to get rating stats:", err)
+		return nil, response.InternalServerError("Failed to get rating stats", nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	for rows.Next() {
+		var stat MenuRatingStats
+		if err := rows.Scan(&stat.MenuId, &stat.Average, &stat.TotalRating, &stat.Distribution.One, &stat.Distribution.Two,
+			&stat.Distribution.Three, &stat.Distribution.Four, &stat.Distribution.Five); err != nil {
+			log.Error("Failed to scan rating stats:", err)
+			return nil, response.InternalServerError("Failed to scan rating stats", nil)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+func (r *transactionRepository) TopMenusReport(request TopMenusReportRequest) ([]TopMenuReport, error) {
+	var report = make([]TopMenuReport, 0)
+
+	// kolom sort diambil dari whitelist, bukan langsung dari query param
+	query := topMenusQuery + fmt.Sprintf(" ORDER BY %s DESC, td.menu_id LIMIT :limit ", topMenuSortFields[request.SortBy])
+
+	args := map[string]interface{}{
+		"start_date": request.StartDate,
+		"end_date":   request.EndDate,
+		"limit":      request.Limit,
+	}
+
+	rows, err := r.db.NamedQuery(query, args)
+	if err != nil {
+		log.Error("Failed to get top menus report:", err)
+		return nil, response.InternalServerError("Failed to get top menus report", nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	for rows.Next() {
+		var menu TopMenuReport
+		if err := rows.StructScan(&menu); err != nil {
+			log.Error("Failed to scan top menus report:", err)
+			return nil, response.InternalServerError("Failed to scan top menus report", nil)
+		}
+		report = append(report, menu)
+	}
+
+	return report, nil
+}
+
+func (r *transactionRepository) PeakHoursReport(request PeakHoursReportRequest) ([]PeakHoursCell, error) {
+	var report = make([]PeakHoursCell, 0)
+
+	args := map[string]interface{}{
+		"start_date": request.StartDate,
+		"end_date":   request.EndDate,
+		"timezone":   request.Timezone,
+	}
+
+	rows, err := r.db.NamedQuery(peakHoursQuery, args)
+	if err != nil {
+		log.Error("Failed to get peak hours report:", err)
+		return nil, response.InternalServerError("Failed to get peak hours report", nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	for rows.Next() {
+		var cell PeakHoursCell
+		if err := rows.StructScan(&cell); err != nil {
+			log.Error("Failed to scan peak hours report:", err)
+			return nil, response.InternalServerError("Failed to scan peak hours report", nil)
+		}
+		report = append(report, cell)
+	}
+
+	return report, nil
+}
+
+func (r *transactionRepository) CohortReport(request CohortReportRequest) ([]CohortReport, error) {
+	var report = make([]CohortReport, 0)
+
+	args := map[string]interface{}{
+		"start_date": request.StartDate,
+		"end_date":   request.EndDate,
+		"timezone":   request.Timezone,
+	}
+
+	rows, err := r.db.NamedQuery(cohortReportQuery, args)
+	if err != nil {
+		log.Error("Failed to get cohort report:", err)
+		return nil, response.InternalServerError("Failed to get cohort report", nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	for rows.Next() {
+		var cohort CohortReport
+		if err := rows.StructScan(&cohort); err != nil {
+			log.Error("Failed to scan cohort report:", err)
+			return nil, response.InternalServerError("Failed to scan cohort report", nil)
+		}
+		report = append(report, cohort)
+	}
+
+	return report, nil
+}
+
+func (r *transactionRepository) SalesBreakdownReport(request SalesBreakdownReportRequest) ([]SalesBreakdownReport, error) {
+	var report = make([]SalesBreakdownReport, 0)
+
+	// pakai tabel agregat kalau semua dimensi tersedia di sana
+	useAggregate := true
+	for _, dimension := range request.Dimensions {
+		if _, ok := salesBreakdownAggregateDimensions[dimension]; !ok {
+			useAggregate = false
+			break
+		}
+	}
+
+	columns := make([]string, 0, len(request.Dimensions))
+	positions := make([]string, 0, len(request.Dimensions))
+	for i, dimension := range request.Dimensions {
+		expression := salesBreakdownDimensions[dimension]
+		if useAggregate {
+			expression = salesBreakdownAggregateDimensions[dimension]
+		}
+		columns = append(columns, fmt.Sprintf("%s AS dimension_%d", expression, i))
+		positions = append(positions, strconv.Itoa(i+1))
+	}
+
+	var query string
+	if useAggregate {
+		query = fmt.Sprintf(`SELECT %s, SUM(s.total) AS total, SUM(s.total_order) AS total_order
+		FROM tr_sales_hourly s
+		WHERE %s >= CAST(:start_date AS DATE) AND %s < CAST(:end_date AS DATE) + 1
+		GROUP BY %s HAVING SUM(s.total_order) > 0 ORDER BY %s`,
+			strings.Join(columns, ", "), localSalesHour, localSalesHour, strings.Join(positions, ", "), strings.Join(positions, ", "))
+	} else {
+		query = fmt.Sprintf(`SELECT %s, SUM(t.total_price) AS total, COUNT(t.id) AS total_order
+		FROM th_user_checkouts t
+		WHERE %s >= CAST(:start_date AS DATE) AND %s < CAST(:end_date AS DATE) + 1
+		GROUP BY %s ORDER BY %s`,
+			strings.Join(columns, ", "), localCreatedAt, localCreatedAt, strings.Join(positions, ", "), strings.Join(positions, ", "))
+	}
+
+	args := map[string]interface{}{
+		"start_date": request.StartDate,
+		"end_date":   request.EndDate,
+		"timezone":   request.Timezone,
+	}
+
+	rows, err := r.db.NamedQuery(query, args)
+	if err != nil {
+		log.Error("Failed to get sales breakdown report:", err)
+		return nil, response.InternalServerError("Failed to get sales breakdown report", nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	for rows.Next() {
+		row := SalesBreakdownReport{Dimensions: make([]SalesBreakdownDimension, len(request.Dimensions))}
+		dest := make([]interface{}, 0, len(request.Dimensions)+2)
+		for i, dimension := range request.Dimensions {
+			row.Dimensions[i].Name = dimension
+			dest = append(dest, &row.Dimensions[i].Value)
+		}
+		dest = append(dest, &row.Total, &row.TotalOrder)
+
+		if err := rows.Scan(dest...); err != nil {
+			log.Error("Failed to scan sales breakdown report:", err)
+			return nil, response.InternalServerError("Failed to scan sales breakdown report", nil)
+		}
+		report = append(report, row)
+	}
+
+	return report, nil
+}
+
 func validateAffectedRows(info sql.Result, message string) error {
 	affected, err := common.GetInfoRowsAffected(info)
 	if err != nil {
@@ -360,3 +902,113 @@ func validateAffectedRows(info sql.Result, message string) error {
 	}
 	return nil
 }
+
+// applyTransactionFilter menambahkan filter list transaksi, dipakai oleh list admin dan history customer
+// supaya setiap filter bekerja sama di kedua endpoint
+func applyTransactionFilter(query string, filter TransactionFilter) (string, map[string]interface{}) {
+	args := map[string]interface{}{}
+	conditions := []string{}
+
+	if filter.StartDate != "" && filter.EndDate != "" {
+		conditions = append(conditions, "CAST(t.created_at AS DATE) BETWEEN :start_date AND :end_date")
+		args["start_date"] = filter.StartDate
+		args["end_date"] = filter.EndDate
+	}
+	if filter.Status != nil {
+		conditions = append(conditions, "t.order_status = :filter_status")
+		args["filter_status"] = *filter.Status
+	}
+	if filter.TableId != 0 {
+		conditions = append(conditions, "t.table_id = :filter_table_id")
+		args["filter_table_id"] = filter.TableId
+	}
+	if detailCondition := buildDetailFilter(filter, args); detailCondition != "" {
+		conditions = append(conditions, detailCondition)
+	}
+
+	if len(conditions) == 0 {
+		return query, args
+	}
+
+	if strings.Contains(strings.ToUpper(query), "WHERE") {
+		query += " AND "
+	} else {
+		query += " WHERE "
+	}
+
+	return query + strings.Join(conditions, " AND ") + " ", args
+}
+
+// buildDetailFilter membuat satu EXISTS untuk filter detail, jadi semua filter harus cocok di detail
+// yang sama (contoh menuId=7&rated=true berarti menu 7 sudah dirating). EXISTS dipakai supaya
+// details tetap berisi semua menu di transaksi dan jumlah row untuk pagination tidak berubah
+func buildDetailFilter(filter TransactionFilter, args map[string]interface{}) string {
+	conditions := []string{}
+
+	if filter.MenuId != 0 {
+		conditions = append(conditions, "tdf.menu_id = :filter_menu_id")
+		args["filter_menu_id"] = filter.MenuId
+	}
+	if filter.Rated != nil {
+		if *filter.Rated {
+			conditions = append(conditions, "tdf.rating IS NOT NULL")
+		} else {
+			conditions = append(conditions, "tdf.rating IS NULL")
+		}
+	}
+	if filter.MinRating != 0 {
+		conditions = append(conditions, "tdf.rating >= :filter_min_rating")
+		args["filter_min_rating"] = filter.MinRating
+	}
+	if filter.HasNotes != nil {
+		if *filter.HasNotes {
+			conditions = append(conditions, "COALESCE(tdf.notes, '') <> ''")
+		} else {
+			conditions = append(conditions, "COALESCE(tdf.notes, '') = ''")
+		}
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "EXISTS (SELECT 1 FROM td_user_checkouts tdf WHERE tdf.ref_id = t.id AND " + strings.Join(conditions, " AND ") + ")"
+}
+
+// applyTextSearch menambahkan filter full-text search dari param q. Kalau user tidak memilih sort,
+// hasil diurutkan berdasarkan relevansi (kecuali cursor pagination yang butuh kolom sort stabil)
+func applyTextSearch(query string, params *common.ParamsListRequest) (string, string) {
+	textQuery := buildTextQuery(params.Query)
+	if textQuery == "" {
+		return query, ""
+	}
+
+	if strings.Contains(strings.ToUpper(query), "WHERE") {
+		query += " AND " + textSearchCondition
+	} else {
+		query += " WHERE " + textSearchCondition
+	}
+
+	if params.DefaultSort && !params.UseCursor {
+		params.Sort = []common.Sort{
+			{Field: textSearchRank, Order: common.SortDesc},
+			{Field: "t.id", Order: common.SortDesc},
+		}
+	}
+
+	return query, textQuery
+}
+
+// buildTextQuery mengubah kata kunci menjadi prefix tsquery, contoh "oat milk" -> "oat:* & milk:*"
+func buildTextQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+
+	return strings.Join(terms, " & ")
+}
@@ -1,6 +1,13 @@
 package transaction
 
 import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
 	"eka-dev.cloud/transaction-service/lib"
 	"eka-dev.cloud/transaction-service/middleware"
 	"eka-dev.cloud/transaction-service/utils/common"
@@ -17,9 +24,21 @@ type Handler interface {
 	GetOneTransaction(c *fiber.Ctx) error
 	GetListTransactionsByUserId(c *fiber.Ctx) error
 	GetOneTransactionByUserId(c *fiber.Ctx) error
+	GetReceipt(c *fiber.Ctx) error
+	GetReceiptByUserId(c *fiber.Ctx) error
 	UpdateOrderStatus(c *fiber.Ctx) error
 	SetRatingMenu(c *fiber.Ctx) error
+	SetRatingService(c *fiber.Ctx) error
+	GetListServiceRatings(c *fiber.Ctx) error
 	SummaryReportTransactions(c *fiber.Ctx) error
+	GetRatingStats(c *fiber.Ctx) error
+	TopMenusReport(c *fiber.Ctx) error
+	SalesBreakdownReport(c *fiber.Ctx) error
+	PeakHoursReport(c *fiber.Ctx) error
+	CohortReport(c *fiber.Ctx) error
+	CreateDailyClosing(c *fiber.Ctx) error
+	GetListDailyClosings(c *fiber.Ctx) error
+	PrintDailyClosing(c *fiber.Ctx) error
 }
 
 type handler struct {
@@ -38,9 +57,21 @@ func NewHandler(app *fiber.App, db *sqlx.DB) Handler {
 	routes.Get("/transactions/detail", middleware.RequireRole("admin", "barista"), h.GetOneTransaction)
 	routes.Get("/history-checkouts", middleware.RequireAuth, h.GetListTransactionsByUserId)
 	routes.Get("/history-checkouts/detail", middleware.RequireAuth, h.GetOneTransactionByUserId)
+	routes.Get("/transactions/receipt", middleware.RequireRole("admin", "barista"), h.GetReceipt)
+	routes.Get("/history-checkouts/receipt", middleware.RequireAuth, h.GetReceiptByUserId)
 	routes.Patch("/transactions/update-order-status", middleware.RequireRole("admin", "barista"), h.UpdateOrderStatus)
 	routes.Patch("/history-checkouts/set-rating-menu", middleware.RequireAuth, h.SetRatingMenu)
+	routes.Patch("/history-checkouts/set-rating-service", middleware.RequireAuth, h.SetRatingService)
+	routes.Get("/transactions/service-ratings", middleware.RequireRole("admin"), h.GetListServiceRatings)
 	routes.Get("/transactions/summary-report", middleware.RequireRole("admin", "barista"), h.SummaryReportTransactions)
+	routes.Get("/transactions/rating-stats", middleware.RequireRole("admin"), h.GetRatingStats)
+	routes.Get("/transactions/top-menus-report", middleware.RequireRole("admin"), h.TopMenusReport)
+	routes.Get("/transactions/sales-breakdown-report", middleware.RequireRole("admin"), h.SalesBreakdownReport)
+	routes.Get("/transactions/peak-hours-report", middleware.RequireRole("admin"), h.PeakHoursReport)
+	routes.Get("/transactions/cohort-report", middleware.RequireRole("admin"), h.CohortReport)
+	routes.Post("/transactions/closings", middleware.RequireRole("admin"), h.CreateDailyClosing)
+	routes.Get("/transactions/closings", middleware.RequireRole("admin"), h.GetListDailyClosings)
+	routes.Get("/transactions/closings/print", middleware.RequireRole("admin"), h.PrintDailyClosing)
 
 	// routes.Get("", h.GetSomething)
 
@@ -80,41 +111,49 @@ func (h *handler) GetListTransactions(c *fiber.Ctx) error {
 	// Parse query parameters
 	queryParams := c.Queries()
 	var paramsListRequest common.ParamsListRequest
-	if err := common.ParseQueryParams(queryParams, &paramsListRequest); err != nil {
+	if err := common.ParseQueryParams(c, &paramsListRequest); err != nil {
 		return err
 	}
 
-	startDate := queryParams["startDate"]
-	endDate := queryParams["endDate"]
-
-	if startDate != "" && endDate != "" {
-		dateRequest := common.DateOrder{}
-		err := c.QueryParser(&dateRequest)
-		if err != nil {
-			log.Error("Failed to parse request query:", err)
-			return response.BadRequest("Invalid request query", nil)
-		}
-
-		err = lib.ValidateRequest(dateRequest)
-		if err != nil {
-			return err
-		}
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return err
 	}
 
 	var request = GetListTransactionsRequest{
 		ParamsListRequest: paramsListRequest,
-		StartDate:         startDate,
-		EndDate:           endDate,
+		TransactionFilter: *filter,
+		Format:            queryParams["format"],
 	}
 
-	err := lib.ValidateRequest(request)
+	err = lib.ValidateRequest(request)
 	if err != nil {
 		return err
 	}
 
+	switch request.Format {
+	case lib.ExportFormatXLSX, lib.ExportFormatCSV, lib.ExportFormatNDJSON:
+		// query dibentuk dulu supaya param yang salah dibalas 400 sebelum status 200 terkirim
+		streamQuery, err := h.service.PrepareStreamTransactions(request)
+		if err != nil {
+			return err
+		}
+		if request.Format != lib.ExportFormatXLSX {
+			return h.streamTransactions(c, request.Format, streamQuery)
+		}
+
+		file, err := h.service.ExportTransactions(streamQuery)
+		if err != nil {
+			return err
+		}
+		return sendExportFile(c, request.Format, "transactions", file)
+	}
+
 	var records interface{}
 	if paramsListRequest.NoPaginate {
 		records, err = h.service.GetListTransactionsNoPagination(request)
+	} else if paramsListRequest.UseCursor {
+		records, err = h.service.GetListTransactionsCursor(request)
 	} else {
 		records, err = h.service.GetListTransactionsPagination(request)
 	}
@@ -122,6 +161,11 @@ func (h *handler) GetListTransactions(c *fiber.Ctx) error {
 		return err
 	}
 
+	records, err = common.SelectFields(records, paramsListRequest.Fields)
+	if err != nil {
+		return err
+	}
+
 	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
 }
 
@@ -142,13 +186,22 @@ func (h *handler) GetOneTransaction(c *fiber.Ctx) error {
 
 func (h *handler) GetListTransactionsByUserId(c *fiber.Ctx) error {
 	// Parse query parameters
-	queryParams := c.Queries()
 	var paramsListRequest common.ParamsListRequest
-	if err := common.ParseQueryParams(queryParams, &paramsListRequest); err != nil {
+	if err := common.ParseQueryParams(c, &paramsListRequest); err != nil {
+		return err
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
 		return err
 	}
 
-	err := lib.ValidateRequest(paramsListRequest)
+	var request = GetListTransactionsRequest{
+		ParamsListRequest: paramsListRequest,
+		TransactionFilter: *filter,
+	}
+
+	err = lib.ValidateRequest(request)
 	if err != nil {
 		return err
 	}
@@ -158,7 +211,17 @@ func (h *handler) GetListTransactionsByUserId(c *fiber.Ctx) error {
 		return err
 	}
 
-	records, err := h.service.GetListTransactionsByUserId(paramsListRequest, claims.UserId, claims.FullName)
+	var records interface{}
+	if paramsListRequest.UseCursor {
+		records, err = h.service.GetListTransactionsByUserIdCursor(request, claims.UserId, claims.FullName)
+	} else {
+		records, err = h.service.GetListTransactionsByUserId(request, claims.UserId, claims.FullName)
+	}
+	if err != nil {
+		return err
+	}
+
+	records, err = common.SelectFields(records, paramsListRequest.Fields)
 	if err != nil {
 		return err
 	}
@@ -186,6 +249,41 @@ func (h *handler) GetOneTransactionByUserId(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response.Success("Success", record))
 }
 
+func (h *handler) GetReceipt(c *fiber.Ctx) error {
+	// Parse query parameter
+	request, err := common.GetOneDataRequest(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.service.GetReceipt(request)
+	if err != nil {
+		return err
+	}
+
+	return sendReceiptFile(c, request.Id, file)
+}
+
+func (h *handler) GetReceiptByUserId(c *fiber.Ctx) error {
+	// Parse query parameter
+	request, err := common.GetOneDataRequest(c)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.service.GetReceiptByUserId(request, claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	return sendReceiptFile(c, request.Id, file)
+}
+
 func (h *handler) UpdateOrderStatus(c *fiber.Ctx) error {
 	// Parse request body
 	var request UpdateOrderStatusRequest
@@ -223,6 +321,16 @@ func (h *handler) SetRatingMenu(c *fiber.Ctx) error {
 		return response.BadRequest("Invalid request body", nil)
 	}
 
+	// foto review hanya bisa dikirim lewat multipart/form-data
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			log.Error("Failed to parse multipart form:", err)
+			return response.BadRequest("Invalid request body", nil)
+		}
+		request.Photos = form.File["photos"]
+	}
+
 	err := lib.ValidateRequest(request)
 
 	if err != nil {
@@ -236,7 +344,7 @@ func (h *handler) SetRatingMenu(c *fiber.Ctx) error {
 
 	request.UpdatedBy = claims.UserId
 
-	err = common.WithTransaction[SetRatingMenuRequest](h.db, h.service.SetRatingMenu, request)
+	err = h.service.SetRatingMenu(request)
 	if err != nil {
 		return err
 	}
@@ -244,10 +352,88 @@ func (h *handler) SetRatingMenu(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response.Success("Set rating menu successfully", nil))
 }
 
+func (h *handler) SetRatingService(c *fiber.Ctx) error {
+	// Parse request body
+	var request SetRatingServiceRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.UpdatedBy = claims.UserId
+
+	err = common.WithTransaction[SetRatingServiceRequest](h.db, h.service.SetRatingService, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Set rating service successfully", nil))
+}
+
+func (h *handler) GetListServiceRatings(c *fiber.Ctx) error {
+	// Parse query parameters
+	queryParams := c.Queries()
+	var paramsListRequest common.ParamsListRequest
+	if err := common.ParseQueryParams(c, &paramsListRequest); err != nil {
+		return err
+	}
+
+	var scoreRequest struct {
+		MinScore float64 `query:"minScore"`
+		MaxScore float64 `query:"maxScore"`
+	}
+	if err := c.QueryParser(&scoreRequest); err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	startDate := queryParams["startDate"]
+	endDate := queryParams["endDate"]
+
+	if startDate != "" && endDate != "" {
+		dateRequest := common.DateOrder{StartDate: startDate, EndDate: endDate}
+		err := lib.ValidateRequest(dateRequest)
+		if err != nil {
+			return err
+		}
+	}
+
+	var request = GetListServiceRatingsRequest{
+		ParamsListRequest: paramsListRequest,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		MinScore:          scoreRequest.MinScore,
+		MaxScore:          scoreRequest.MaxScore,
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.GetListServiceRatings(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
 func (h *handler) SummaryReportTransactions(c *fiber.Ctx) error {
 	// Parse query parameters
 
-	var request common.DateOrder
+	var request SummaryReportRequest
 
 	err := c.QueryParser(&request)
 	if err != nil {
@@ -255,15 +441,329 @@ func (h *handler) SummaryReportTransactions(c *fiber.Ctx) error {
 		return response.BadRequest("Invalid request query", nil)
 	}
 
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
 	err = lib.ValidateRequest(request)
 	if err != nil {
 		return err
 	}
 
-	record, err := h.service.SummaryReportTransactions(request.StartDate, request.EndDate)
+	record, err := h.service.SummaryReportTransactions(request)
 	if err != nil {
 		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response.Success("Success", record))
 }
+
+func (h *handler) GetRatingStats(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request RatingStatsRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	err = lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.GetRatingStats(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) TopMenusReport(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request TopMenusReportRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	err = lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.TopMenusReport(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) SalesBreakdownReport(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request SalesBreakdownReportRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	err = lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.SalesBreakdownReport(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) PeakHoursReport(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request PeakHoursReportRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	err = lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.PeakHoursReport(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) CohortReport(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request CohortReportRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	err = lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.CohortReport(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) CreateDailyClosing(c *fiber.Ctx) error {
+	// Parse request body
+	var request CreateDailyClosingRequest
+	if err := c.BodyParser(&request); err != nil {
+		log.Error("Failed to parse request body:", err)
+		return response.BadRequest("Invalid request body", nil)
+	}
+
+	err := lib.ValidateRequest(request)
+	if err != nil {
+		return err
+	}
+
+	claims, err := common.GetClaimsFromLocals(c)
+	if err != nil {
+		return err
+	}
+
+	request.ClosedBy = claims.UserId
+
+	err = common.WithTransaction[CreateDailyClosingRequest](h.db, h.service.CreateDailyClosing, request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(response.Success("Day closed successfully", nil))
+}
+
+func (h *handler) GetListDailyClosings(c *fiber.Ctx) error {
+	// Parse query parameters
+	var request DailyClosingListRequest
+
+	err := c.QueryParser(&request)
+	if err != nil {
+		log.Error("Failed to parse request query:", err)
+		return response.BadRequest("Invalid request query", nil)
+	}
+
+	err = lib.ValidateRequest(common.DateOrder{StartDate: request.StartDate, EndDate: request.EndDate})
+	if err != nil {
+		return err
+	}
+
+	records, err := h.service.GetListDailyClosings(request)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success("Success", records))
+}
+
+func (h *handler) PrintDailyClosing(c *fiber.Ctx) error {
+	// Parse query parameter
+	request, err := common.GetOneDataRequest(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.service.PrintDailyClosing(request)
+	if err != nil {
+		return err
+	}
+
+	c.Attachment(fmt.Sprintf("closing-%d.pdf", request.Id))
+	c.Set(fiber.HeaderContentType, lib.MIMEPDF)
+	return c.Status(fiber.StatusOK).Send(file)
+}
+
+// parseTransactionFilter membaca filter list transaksi, dipakai oleh /transactions dan /history-checkouts
+func parseTransactionFilter(c *fiber.Ctx) (*TransactionFilter, error) {
+	var filter TransactionFilter
+	if err := c.QueryParser(&filter); err != nil {
+		log.Error("Failed to parse request query:", err)
+		return nil, response.BadRequest("Invalid request query", nil)
+	}
+
+	if filter.StartDate != "" || filter.EndDate != "" {
+		err := lib.ValidateRequest(common.DateOrder{StartDate: filter.StartDate, EndDate: filter.EndDate})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := lib.ValidateRequest(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filter, nil
+}
+
+func (h *handler) streamTransactions(c *fiber.Ctx, format string, streamQuery *TransactionStreamQuery) error {
+	if format == lib.ExportFormatCSV {
+		c.Attachment(exportFileName("transactions", format))
+		c.Set(fiber.HeaderContentType, lib.MIMETextCSV)
+	} else {
+		c.Set(fiber.HeaderContentType, lib.MIMENDJSON)
+	}
+
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := json.NewEncoder(w)
+		var csvWriter *csv.Writer
+		if format == lib.ExportFormatCSV {
+			csvWriter = csv.NewWriter(w)
+			_ = csvWriter.Write(transactionExportHeader)
+		}
+
+		err := h.service.StreamTransactions(streamQuery, func(batch []TransactionResponse) error {
+			if csvWriter != nil {
+				for _, row := range transactionExportRows(batch) {
+					if err := csvWriter.Write(lib.CSVRecord(row)); err != nil {
+						return err
+					}
+				}
+				csvWriter.Flush()
+				if err := csvWriter.Error(); err != nil {
+					return err
+				}
+			} else {
+				for _, data := range batch {
+					if err := encoder.Encode(data); err != nil {
+						return err
+					}
+				}
+			}
+			return w.Flush()
+		})
+
+		// status sudah terkirim, jadi error DB atau enrichment di tengah stream ditandai dengan baris penutup
+		if err != nil {
+			log.Error("Failed to stream transactions:", err)
+			if csvWriter != nil {
+				_ = csvWriter.Write([]string{exportErrorMarker, exportErrorMessage})
+			} else {
+				_ = encoder.Encode(fiber.Map{"error": exportErrorMessage})
+			}
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+		_ = w.Flush()
+	})
+
+	return nil
+}
+
+func sendExportFile(c *fiber.Ctx, format string, name string, file *lib.XLSXWriter) error {
+	c.Attachment(exportFileName(name, format))
+	c.Set(fiber.HeaderContentType, lib.MIMEXLSX)
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer file.Close()
+		if _, err := file.WriteTo(w); err != nil {
+			log.Error("Failed to write xlsx file:", err)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+func sendReceiptFile(c *fiber.Ctx, id int, file []byte) error {
+	c.Attachment(fmt.Sprintf("receipt-%d.pdf", id))
+	c.Set(fiber.HeaderContentType, lib.MIMEPDF)
+	return c.Status(fiber.StatusOK).Send(file)
+}
+
+func exportFileName(name string, format string) string {
+	return fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102150405"), format)
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"mime/multipart"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -23,13 +25,31 @@ type Service interface {
 	// TODO: define service methods
 	CreateTransaction(tx *sqlx.Tx, request CreateTransactionRequest) error
 	GetListTransactionsPagination(request GetListTransactionsRequest) (*response.Pagination[[]TransactionResponse], error)
+	GetListTransactionsCursor(request GetListTransactionsRequest) (*response.CursorPagination[[]TransactionResponse], error)
 	GetListTransactionsNoPagination(request GetListTransactionsRequest) ([]TransactionResponse, error)
+	ExportTransactions(query *TransactionStreamQuery) (*lib.XLSXWriter, error)
+	PrepareStreamTransactions(request GetListTransactionsRequest) (*TransactionStreamQuery, error)
+	StreamTransactions(query *TransactionStreamQuery, fn func([]TransactionResponse) error) error
 	GetOneTransaction(request *common.OneRequest) (*TransactionResponse, error)
-	GetListTransactionsByUserId(request common.ParamsListRequest, userId int64, name string) (*response.Pagination[[]TransactionResponse], error)
+	GetListTransactionsByUserId(request GetListTransactionsRequest, userId int64, name string) (*response.Pagination[[]TransactionResponse], error)
+	GetListTransactionsByUserIdCursor(request GetListTransactionsRequest, userId int64, name string) (*response.CursorPagination[[]TransactionResponse], error)
 	GetOneTransactionByUserId(request *common.OneRequest, userId int64, name string) (*TransactionResponse, error)
+	GetMenusAndTable(menuIds string, tableIds string) (GetMenusAndTableResponse, error)
+	GetReceipt(request *common.OneRequest) ([]byte, error)
+	GetReceiptByUserId(request *common.OneRequest, userId int64) ([]byte, error)
 	UpdateOrderStatus(tx *sqlx.Tx, request UpdateOrderStatusRequest) error
-	SetRatingMenu(tx *sqlx.Tx, request SetRatingMenuRequest) error
-	SummaryReportTransactions(startDate string, endDate string) ([]SummaryReport, error)
+	SetRatingMenu(request SetRatingMenuRequest) error
+	SetRatingService(tx *sqlx.Tx, request SetRatingServiceRequest) error
+	GetListServiceRatings(request GetListServiceRatingsRequest) (*response.Pagination[[]ServiceRatingResponse], error)
+	SummaryReportTransactions(request SummaryReportRequest) ([]SummaryReport, error)
+	GetRatingStats(request RatingStatsRequest) ([]MenuRatingStats, error)
+	TopMenusReport(request TopMenusReportRequest) ([]TopMenuReport, error)
+	SalesBreakdownReport(request SalesBreakdownReportRequest) ([]SalesBreakdownReport, error)
+	PeakHoursReport(request PeakHoursReportRequest) ([]PeakHoursDay, error)
+	CohortReport(request CohortReportRequest) ([]CohortReport, error)
+	CreateDailyClosing(tx *sqlx.Tx, request CreateDailyClosingRequest) error
+	GetListDailyClosings(request DailyClosingListRequest) ([]DailyClosing, error)
+	PrintDailyClosing(request *common.OneRequest) ([]byte, error)
 }
 
 type transactionService struct {
@@ -42,6 +62,16 @@ func NewTransactionService(repo Repository, db *sqlx.DB) Service {
 }
 
 func (s *transactionService) CreateTransaction(tx *sqlx.Tx, request CreateTransactionRequest) error {
+	// cek closing sebelum potong saldo wallet, lock tanggal ditahan sampai transaksi selesai
+	// supaya closing hari ini menunggu checkout yang sedang berjalan
+	closed, err := s.repo.IsTodayClosed(tx)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return response.BadRequest("Today's sales are already closed", nil)
+	}
+
 	// Convert menuIds slice to a comma-separated string
 	var ids string
 	for i, data := range request.Datas {
@@ -61,7 +91,7 @@ func (s *transactionService) CreateTransaction(tx *sqlx.Tx, request CreateTransa
 
 	request.Total = calculateTotalPriceMenu(menus, &request)
 
-	err = paymentUseWallet(request.CreatedBy, request.Total, request.Pin)
+	request.Payment, err = paymentUseWallet(request.CreatedBy, request.Total, request.Pin)
 	if err != nil {
 		return err
 	}
@@ -82,149 +112,101 @@ func (s *transactionService) CreateTransaction(tx *sqlx.Tx, request CreateTransa
 }
 
 func (s *transactionService) GetListTransactionsPagination(request GetListTransactionsRequest) (*response.Pagination[[]TransactionResponse], error) {
-	res, err := s.repo.GetListTransactionsPagination(request.ParamsListRequest, request.StartDate, request.EndDate)
+	expand, err := newTransactionExpand(request.ParamsListRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	menuIds := []string{}
-	tableIds := []string{}
-	userIds := []string{}
-	for _, data := range res.Data {
-		tableIdStr := utils.Int64ToString(data.TableId)
-
-		if data.TableId != 0 && !strings.Contains(strings.Join(tableIds, ","), tableIdStr) {
-			tableIds = append(tableIds, tableIdStr)
-		}
-
-		if data.UserId != 0 && !strings.Contains(strings.Join(userIds, ","), utils.Int64ToString(data.UserId)) {
-			userIds = append(userIds, utils.Int64ToString(data.UserId))
-		}
-
-		for _, detail := range data.Details {
-			menuIdStr := utils.IntToString(detail.MenuId)
-			if detail.MenuId != 0 && !strings.Contains(strings.Join(menuIds, ","), menuIdStr) {
-				menuIds = append(menuIds, menuIdStr)
-			}
-		}
+	res, err := s.repo.GetListTransactionsPagination(request.ParamsListRequest, request.TransactionFilter)
+	if err != nil {
+		return nil, err
 	}
 
-	menuIdsStr := strings.Join(menuIds, ",")
-	tableIdsStr := strings.Join(tableIds, ",")
-	userIdsStr := strings.Join(userIds, ",")
-
-	if menuIdsStr != "" && tableIdsStr != "" && userIdsStr != "" {
-		dataMenusAndTable, err := getDataMenuByIdsAndTable(menuIdsStr, tableIdsStr)
-		if err != nil {
-			return nil, err
-		}
-		dataUsers, err := getUsersNameByIds(userIdsStr)
-		if err != nil {
-			return nil, err
-		}
+	err = enrichTransactions(res.Data, expand)
+	if err != nil {
+		return nil, err
+	}
 
-		for i, data := range res.Data {
+	return res, nil
+}
 
-			for idDataDetail, dataDetail := range data.Details {
-				for _, menu := range dataMenusAndTable.Menus {
-					if dataDetail.MenuId == menu.Id {
-						res.Data[i].Details[idDataDetail].MenuName = menu.Name
-						res.Data[i].Details[idDataDetail].Photo = menu.Photo
-						res.Data[i].Details[idDataDetail].Description = menu.Description
-						break
-					}
-				}
-			}
-			for _, table := range dataMenusAndTable.Tables {
-				if data.TableId == table.Id {
-					res.Data[i].TableName = table.Name
-					break
-				}
-			}
-			for _, user := range dataUsers {
-				if data.UserId == user.UserId {
-					res.Data[i].OrderBy = user.FullName
-					break
-				}
-			}
+func (s *transactionService) GetListTransactionsCursor(request GetListTransactionsRequest) (*response.CursorPagination[[]TransactionResponse], error) {
+	expand, err := newTransactionExpand(request.ParamsListRequest)
+	if err != nil {
+		return nil, err
+	}
 
-		}
+	res, err := s.repo.GetListTransactionsCursor(request.ParamsListRequest, request.TransactionFilter)
+	if err != nil {
+		return nil, err
+	}
 
+	err = enrichTransactions(res.Data, expand)
+	if err != nil {
+		return nil, err
 	}
+
 	return res, nil
 }
 
 func (s *transactionService) GetListTransactionsNoPagination(request GetListTransactionsRequest) ([]TransactionResponse, error) {
-	res, err := s.repo.GetListTransactionsNoPagination(request.ParamsListRequest, request.StartDate, request.EndDate)
+	expand, err := newTransactionExpand(request.ParamsListRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	menuIds := []string{}
-	tableIds := []string{}
-	userIds := []string{}
-	for _, data := range res {
-		tableIdStr := utils.Int64ToString(data.TableId)
+	res, err := s.repo.GetListTransactionsNoPagination(request.ParamsListRequest, request.TransactionFilter)
+	if err != nil {
+		return nil, err
+	}
 
-		if data.TableId != 0 && !strings.Contains(strings.Join(tableIds, ","), tableIdStr) {
-			tableIds = append(tableIds, tableIdStr)
-		}
+	err = enrichTransactions(res, expand)
+	if err != nil {
+		return nil, err
+	}
 
-		if data.UserId != 0 && !strings.Contains(strings.Join(userIds, ","), utils.Int64ToString(data.UserId)) {
-			userIds = append(userIds, utils.Int64ToString(data.UserId))
-		}
+	return res, nil
+}
 
-		for _, detail := range data.Details {
-			menuIdStr := utils.IntToString(detail.MenuId)
-			if detail.MenuId != 0 && !strings.Contains(strings.Join(menuIds, ","), menuIdStr) {
-				menuIds = append(menuIds, menuIdStr)
-			}
-		}
+// ExportTransactions membuat XLSX dari batch StreamListTransactions. File dibuat lengkap sebelum
+// response dikirim supaya error di tengah export masih bisa dibalas dengan status error
+func (s *transactionService) ExportTransactions(query *TransactionStreamQuery) (*lib.XLSXWriter, error) {
+	file, err := lib.NewXLSXWriter("Transactions", transactionExportHeader)
+	if err != nil {
+		return nil, err
 	}
 
-	menuIdsStr := strings.Join(menuIds, ",")
-	tableIdsStr := strings.Join(tableIds, ",")
-	userIdsStr := strings.Join(userIds, ",")
-
-	if menuIdsStr != "" && tableIdsStr != "" && userIdsStr != "" {
-		dataMenusAndTable, err := getDataMenuByIdsAndTable(menuIdsStr, tableIdsStr)
-		if err != nil {
-			return nil, err
-		}
-		dataUsers, err := getUsersNameByIds(userIdsStr)
-		if err != nil {
-			return nil, err
-		}
+	err = s.StreamTransactions(query, func(batch []TransactionResponse) error {
+		return file.WriteRows(transactionExportRows(batch))
+	})
+	if err == nil {
+		err = file.Flush()
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
 
-		for i, data := range res {
-			for idDataDetail, dataDetail := range data.Details {
-				for _, menu := range dataMenusAndTable.Menus {
-					if dataDetail.MenuId == menu.Id {
-						res[i].Details[idDataDetail].MenuName = menu.Name
-						res[i].Details[idDataDetail].Photo = menu.Photo
-						res[i].Details[idDataDetail].Description = menu.Description
-						break
-					}
-				}
-			}
-			for _, table := range dataMenusAndTable.Tables {
-				if data.TableId == table.Id {
-					res[i].TableName = table.Name
-					break
-				}
-			}
+	return file, nil
+}
 
-			for _, user := range dataUsers {
-				if data.UserId == user.UserId {
-					res[i].OrderBy = user.FullName
-					break
-				}
-			}
+func (s *transactionService) PrepareStreamTransactions(request GetListTransactionsRequest) (*TransactionStreamQuery, error) {
+	return s.repo.BuildStreamListTransactionsQuery(request.ParamsListRequest, request.TransactionFilter)
+}
 
-		}
+func (s *transactionService) StreamTransactions(query *TransactionStreamQuery, fn func([]TransactionResponse) error) error {
+	batchSize := config.Config.ExportBatchSize
+	if batchSize <= 0 {
+		batchSize = 500
 	}
 
-	return res, nil
+	return s.repo.StreamListTransactions(query, batchSize, func(batch []TransactionResponse) error {
+		err := enrichTransactions(batch, expandAll)
+		if err != nil {
+			return err
+		}
+		return fn(batch)
+	})
 }
 
 func (s *transactionService) GetOneTransaction(request *common.OneRequest) (*TransactionResponse, error) {
@@ -237,10 +219,11 @@ func (s *transactionService) GetOneTransaction(request *common.OneRequest) (*Tra
 	tableIdStr := utils.Int64ToString(res.TableId)
 	userIdStr := utils.Int64ToString(res.UserId)
 
+	seenMenus := map[int64]struct{}{}
 	for _, detail := range res.Details {
-		menuIdStr := utils.IntToString(detail.MenuId)
-		if detail.MenuId != 0 && !strings.Contains(strings.Join(menuIds, ","), menuIdStr) {
-			menuIds = append(menuIds, menuIdStr)
+		if _, ok := seenMenus[int64(detail.MenuId)]; detail.MenuId != 0 && !ok {
+			seenMenus[int64(detail.MenuId)] = struct{}{}
+			menuIds = append(menuIds, utils.IntToString(detail.MenuId))
 		}
 	}
 
@@ -277,57 +260,39 @@ func (s *transactionService) GetOneTransaction(request *common.OneRequest) (*Tra
 	return res, nil
 }
 
-func (s *transactionService) GetListTransactionsByUserId(request common.ParamsListRequest, userId int64, name string) (*response.Pagination[[]TransactionResponse], error) {
-	res, err := s.repo.GetListTransactionsByUserId(request, userId)
+func (s *transactionService) GetListTransactionsByUserId(request GetListTransactionsRequest, userId int64, name string) (*response.Pagination[[]TransactionResponse], error) {
+	expand, err := newTransactionExpand(request.ParamsListRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	menuIds := []string{}
-	tableIds := []string{}
-	for _, data := range res.Data {
-		tableIdStr := utils.Int64ToString(data.TableId)
-
-		if data.TableId != 0 && !strings.Contains(strings.Join(tableIds, ","), tableIdStr) {
-			tableIds = append(tableIds, tableIdStr)
-		}
+	res, err := s.repo.GetListTransactionsByUserId(request.ParamsListRequest, request.TransactionFilter, userId)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, detail := range data.Details {
-			menuIdStr := utils.IntToString(detail.MenuId)
-			if detail.MenuId != 0 && !strings.Contains(strings.Join(menuIds, ","), menuIdStr) {
-				menuIds = append(menuIds, menuIdStr)
-			}
-		}
+	err = enrichUserTransactions(res.Data, name, expand)
+	if err != nil {
+		return nil, err
 	}
 
-	menuIdsStr := strings.Join(menuIds, ",")
-	tableIdsStr := strings.Join(tableIds, ",")
-	if menuIdsStr != "" && tableIdsStr != "" {
-		dataMenusAndTable, err := getDataMenuByIdsAndTable(menuIdsStr, tableIdsStr)
-		if err != nil {
-			return nil, err
-		}
+	return res, nil
+}
 
-		for i, data := range res.Data {
-			for idDataDetail, dataDetail := range data.Details {
-				for _, menu := range dataMenusAndTable.Menus {
-					if dataDetail.MenuId == menu.Id {
-						res.Data[i].Details[idDataDetail].MenuName = menu.Name
-						res.Data[i].Details[idDataDetail].Photo = menu.Photo
-						res.Data[i].Details[idDataDetail].Description = menu.Description
-						break
-					}
-				}
-			}
-			for _, table := range dataMenusAndTable.Tables {
-				if data.TableId == table.Id {
-					res.Data[i].TableName = table.Name
-					break
-				}
-			}
+func (s *transactionService) GetListTransactionsByUserIdCursor(request GetListTransactionsRequest, userId int64, name string) (*response.CursorPagination[[]TransactionResponse], error) {
+	expand, err := newTransactionExpand(request.ParamsListRequest)
+	if err != nil {
+		return nil, err
+	}
 
-			res.Data[i].OrderBy = name
-		}
+	res, err := s.repo.GetListTransactionsByUserIdCursor(request.ParamsListRequest, request.TransactionFilter, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enrichUserTransactions(res.Data, name, expand)
+	if err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -342,10 +307,11 @@ func (s *transactionService) GetOneTransactionByUserId(request *common.OneReques
 	menuIds := []string{}
 	tableIdStr := utils.Int64ToString(res.TableId)
 
+	seenMenus := map[int64]struct{}{}
 	for _, detail := range res.Details {
-		menuIdStr := utils.IntToString(detail.MenuId)
-		if detail.MenuId != 0 && !strings.Contains(strings.Join(menuIds, ","), menuIdStr) {
-			menuIds = append(menuIds, menuIdStr)
+		if _, ok := seenMenus[int64(detail.MenuId)]; detail.MenuId != 0 && !ok {
+			seenMenus[int64(detail.MenuId)] = struct{}{}
+			menuIds = append(menuIds, utils.IntToString(detail.MenuId))
 		}
 	}
 
@@ -377,113 +343,864 @@ func (s *transactionService) GetOneTransactionByUserId(request *common.OneReques
 	return res, nil
 }
 
+// GetMenusAndTable mengambil data menu dan meja dari master-data, dipakai juga oleh module cart
+func (s *transactionService) GetMenusAndTable(menuIds string, tableIds string) (GetMenusAndTableResponse, error) {
+	return getDataMenuByIdsAndTable(menuIds, tableIds)
+}
+
+func (s *transactionService) GetReceipt(request *common.OneRequest) ([]byte, error) {
+	res, err := s.repo.GetOneTransaction(request.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderReceipt(res)
+}
+
+func (s *transactionService) GetReceiptByUserId(request *common.OneRequest, userId int64) ([]byte, error) {
+	res, err := s.repo.GetOneTransactionByUserId(request.Id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderReceipt(res)
+}
+
 func (s *transactionService) UpdateOrderStatus(tx *sqlx.Tx, request UpdateOrderStatusRequest) error {
-	err := s.repo.UpdateOrderStatus(tx, request.Id, request.UpdatedBy)
+	err := s.validateTransactionDayOpen(tx, request.Id)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.repo.UpdateOrderStatus(tx, request.Id, request.UpdatedBy)
 }
 
-func (s *transactionService) SetRatingMenu(tx *sqlx.Tx, request SetRatingMenuRequest) error {
-	idMenu, err := s.repo.SetRatingMenu(tx, request.Id, request.Rating, request.UpdatedBy)
+// validateTransactionDayOpen menolak perubahan transaksi di hari yang sudah closing. IsTransactionDayClosed
+// mengambil lock tanggal transaksi, jadi closing yang berjalan bersamaan menunggu sampai tx ini selesai
+func (s *transactionService) validateTransactionDayOpen(tx *sqlx.Tx, id int) error {
+	closed, err := s.repo.IsTransactionDayClosed(tx, id)
 	if err != nil {
 		return err
 	}
+	if closed {
+		return response.BadRequest("Transaction day is already closed", nil)
+	}
+	return nil
+}
 
-	ch, err := lib.GetChannel()
+func (s *transactionService) CreateDailyClosing(tx *sqlx.Tx, request CreateDailyClosingRequest) error {
+	// lock diambil sebelum menghitung total supaya checkout dan update status di tanggal ini menunggu
+	future, err := s.repo.LockClosingDate(tx, request.ClosingDate)
 	if err != nil {
-		log.Error("Failed to get channel:", err)
-		return response.InternalServerError("Internal Server Error", nil)
+		return err
 	}
-	payload := []byte(fmt.Sprintf(`{"id": %d, "rating": %d, "updatedBy": %d}`, idMenu, request.Rating, request.UpdatedBy))
-	err = lib.SendMessage(ch, "menu.set_rating", "menu.set_rating", "", lib.ExchangeDirect, amqp.Publishing{
-		ContentType: "application/json",
-		Body:        payload,
-	}, string(payload), true, false, false, amqp.Table{})
+	if future {
+		return response.BadRequest("Cannot close a future date", nil)
+	}
+
+	totals, err := s.repo.GetDailyClosingTotals(tx, request.ClosingDate)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	if totals.TotalUnfinished > 0 {
+		return response.BadRequest(fmt.Sprintf("There are still %d unfinished orders on this day", totals.TotalUnfinished), nil)
+	}
 
-func (s *transactionService) SummaryReportTransactions(startDate string, endDate string) ([]SummaryReport, error) {
-	return s.repo.SummaryReportTransactions(startDate, endDate)
+	return s.repo.InsertDailyClosing(tx, request, totals)
 }
 
-func calculateTotalPriceMenu(menus []MenuResponse, request *CreateTransactionRequest) float64 {
-	var total float64
-	for _, menu := range menus {
-		for iD, data := range request.Datas {
-			if menu.Id == data.MenuID {
-				request.Datas[iD].Price = menu.Price
-				request.Datas[iD].Total = menu.Price * float64(data.Qty)
-				total += menu.Price * float64(data.Qty)
-			}
-		}
+func (s *transactionService) GetListDailyClosings(request DailyClosingListRequest) ([]DailyClosing, error) {
+	res, err := s.repo.GetListDailyClosings(request)
+	if err != nil {
+		return nil, err
 	}
 
-	return total
-}
+	err = enrichDailyClosings(res)
+	if err != nil {
+		return nil, err
+	}
 
-func createSignature(params string, body string, timestamp string) (string, error) {
+	return res, nil
+}
 
-	message := params + timestamp + body
-	
-	slog.Info("Generating HMAC Signature", 
-		slog.String("payload", message),
-		slog.String("params", params),
-		slog.String("body", body),
-	)
+func (s *transactionService) PrintDailyClosing(request *common.OneRequest) ([]byte, error) {
+	res, err := s.repo.GetOneDailyClosing(request.Id)
+	if err != nil {
+		return nil, err
+	}
 
-	signature, err := utils.GenerateHMAC(message)
+	records := []DailyClosing{*res}
+	err = enrichDailyClosings(records)
 	if err != nil {
-		slog.Error("Failed to generate HMAC", slog.String("error", err.Error()))
-		return "", response.InternalServerError("Internal Server Error", nil)
+		return nil, err
 	}
 
-	return signature, nil
+	return generateDailyClosingPDF(&records[0])
 }
 
-func paymentUseWallet(userId int64, total float64, pin string) error {
-	// Implement the logic to check the user's wallet balance
-	urlWallet := fmt.Sprintf("%s/api/internal/pay", config.Config.ServiceWalletUrl)
-
-	// Create the request body
-	bodyRequest := PaymentRequest{
-		UserId: userId,
-		Amount: total,
-		Pin:    pin,
+// SetRatingMenu membuka transaksi sendiri karena foto di MinIO baru boleh dihapus setelah commit:
+// foto baru dihapus kalau transaksi gagal, foto lama dihapus kalau commit berhasil
+func (s *transactionService) SetRatingMenu(request SetRatingMenuRequest) error {
+	if len(request.Photos) > maxRatingPhotos {
+		return response.BadRequest(fmt.Sprintf("Maximum %d photos are allowed", maxRatingPhotos), nil)
+	}
+	for _, photo := range request.Photos {
+		if _, err := lib.ValidateImageFile(photo); err != nil {
+			return err
+		}
 	}
 
-	timestamp := time.Now().UTC().Format(time.RFC3339)
+	var photoPaths, oldPhotoPaths []string
+	err := common.WithTransaction(s.db, func(tx *sqlx.Tx, request SetRatingMenuRequest) error {
+		detail, err := s.repo.GetRatingDetail(tx, request.Id, config.Config.RatingWindowDays)
+		if err != nil {
+			return err
+		}
 
-	// Marshal ke JSON (sekali saja)
-	bodyBytes, err := json.Marshal(bodyRequest)
-	if err != nil {
-		return err
-	}
+		err = validateRatingEligibility(detail.RatingEligibility, request.UpdatedBy)
+		if err != nil {
+			return err
+		}
 
-	// Simpan versi string-nya untuk signature
-	bodyString := string(bodyBytes)
+		err = s.validateTransactionDayOpen(tx, detail.RefId)
+		if err != nil {
+			return err
+		}
 
-	signature, err := createSignature("", bodyString, timestamp)
+		err = s.repo.SetRatingMenu(tx, request.Id, request.Rating, request.Review, request.UpdatedBy)
+		if err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
-	}
+		var photoUrls []string
+		photoUrls, photoPaths, err = uploadRatingPhotos(request.Id, request.Photos)
+		if err != nil {
+			return err
+		}
 
-	_, err = utils.InternalRequest(signature, timestamp, urlWallet, "POST", bytes.NewReader(bodyBytes))
+		err = s.saveRatingPhotosAndNotify(tx, request, detail, photoUrls)
+		if err != nil {
+			return err
+		}
 
+		if len(photoUrls) > 0 {
+			oldPhotoPaths = ratingPhotoPaths(detail.ReviewPhotos)
+		}
+		return nil
+	}, request)
 	if err != nil {
+		deleteRatingPhotos(photoPaths)
 		return err
 	}
 
+	deleteRatingPhotos(oldPhotoPaths)
+
 	return nil
 }
 
+func (s *transactionService) saveRatingPhotosAndNotify(tx *sqlx.Tx, request SetRatingMenuRequest, detail *RatingDetail, photoUrls []string) error {
+	photos := []string(detail.ReviewPhotos)
+	if len(photoUrls) > 0 {
+		err := s.repo.SetRatingPhotos(tx, request.Id, photoUrls)
+		if err != nil {
+			return err
+		}
+		photos = photoUrls
+	}
+
+	review := detail.Review
+	if request.Review != nil {
+		review = *request.Review
+	}
+
+	queueName := queueSetRatingMenu
+	if detail.Rating != nil {
+		queueName = queueUpdateRatingMenu
+	}
+
+	return publishEvent(queueName, RatingMenuEvent{
+		Id:             detail.MenuId,
+		Rating:         request.Rating,
+		PreviousRating: detail.Rating,
+		Review:         review,
+		Photos:         photos,
+		UpdatedBy:      request.UpdatedBy,
+	})
+}
+
+func (s *transactionService) SetRatingService(tx *sqlx.Tx, request SetRatingServiceRequest) error {
+	detail, err := s.repo.GetServiceRatingDetail(tx, request.Id, config.Config.RatingWindowDays)
+	if err != nil {
+		return err
+	}
+
+	err = validateRatingEligibility(detail.RatingEligibility, request.UpdatedBy)
+	if err != nil {
+		return err
+	}
+
+	err = s.validateTransactionDayOpen(tx, request.Id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.SetRatingService(tx, request)
+	if err != nil {
+		return err
+	}
+
+	queueName := queueSetServiceRating
+	if detail.SpeedRating != nil {
+		queueName = queueUpdateServiceRating
+	}
+
+	return publishEvent(queueName, ServiceRatingEvent{
+		Id:                         request.Id,
+		SpeedRating:                request.SpeedRating,
+		FriendlinessRating:         request.FriendlinessRating,
+		PreviousSpeedRating:        detail.SpeedRating,
+		PreviousFriendlinessRating: detail.FriendlinessRating,
+		Comment:                    request.Comment,
+		UpdatedBy:                  request.UpdatedBy,
+	})
+}
+
+func (s *transactionService) GetListServiceRatings(request GetListServiceRatingsRequest) (*response.Pagination[[]ServiceRatingResponse], error) {
+	res, err := s.repo.GetListServiceRatings(request)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := []string{}
+	seenUsers := map[int64]struct{}{}
+	for _, data := range res.Data {
+		if _, ok := seenUsers[data.UserId]; data.UserId != 0 && !ok {
+			seenUsers[data.UserId] = struct{}{}
+			userIds = append(userIds, utils.Int64ToString(data.UserId))
+		}
+	}
+
+	userIdsStr := strings.Join(userIds, ",")
+	if userIdsStr != "" {
+		dataUsers, err := getUsersNameByIds(userIdsStr)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, data := range res.Data {
+			for _, user := range dataUsers {
+				if data.UserId == user.UserId {
+					res.Data[i].OrderBy = user.FullName
+					break
+				}
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (s *transactionService) SummaryReportTransactions(request SummaryReportRequest) ([]SummaryReport, error) {
+	if request.Granularity == "" {
+		request.Granularity = granularityDay
+	}
+
+	if request.Granularity == granularityHour {
+		startDate, _ := time.Parse("2006-01-02", request.StartDate)
+		endDate, _ := time.Parse("2006-01-02", request.EndDate)
+		if endDate.Sub(startDate) >= maxHourlyReportDays*24*time.Hour {
+			return nil, response.BadRequest(fmt.Sprintf("Hourly granularity is limited to %d days", maxHourlyReportDays), nil)
+		}
+	}
+
+	return s.repo.SummaryReportTransactions(request)
+}
+
+func (s *transactionService) GetRatingStats(request RatingStatsRequest) ([]MenuRatingStats, error) {
+	res, err := s.repo.GetRatingStats(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	menuIds := make([]string, 0, len(res))
+	for _, data := range res {
+		menuIds = append(menuIds, utils.IntToString(data.MenuId))
+	}
+
+	dataMenusAndTable, err := getDataMenuByIdsAndTable(strings.Join(menuIds, ","), "")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, data := range res {
+		for _, menu := range dataMenusAndTable.Menus {
+			if data.MenuId == menu.Id {
+				res[i].MenuName = menu.Name
+				res[i].Photo = menu.Photo
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (s *transactionService) TopMenusReport(request TopMenusReportRequest) ([]TopMenuReport, error) {
+	if request.SortBy == "" {
+		request.SortBy = "revenue"
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultTopMenuLimit
+	}
+
+	res, err := s.repo.TopMenusReport(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	menuIds := make([]string, 0, len(res))
+	for _, data := range res {
+		menuIds = append(menuIds, utils.IntToString(data.MenuId))
+	}
+
+	dataMenusAndTable, err := getDataMenuByIdsAndTable(strings.Join(menuIds, ","), "")
+	if err != nil {
+		return nil, err
+	}
+
+	for i, data := range res {
+		for _, menu := range dataMenusAndTable.Menus {
+			if data.MenuId == menu.Id {
+				res[i].MenuName = menu.Name
+				res[i].Photo = menu.Photo
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (s *transactionService) SalesBreakdownReport(request SalesBreakdownReportRequest) ([]SalesBreakdownReport, error) {
+	request.Dimensions = []string{}
+	seenDimensions := map[string]struct{}{}
+	for _, dimension := range strings.Split(request.GroupBy, ",") {
+		dimension = strings.TrimSpace(dimension)
+		if _, ok := salesBreakdownDimensions[dimension]; !ok {
+			return nil, response.BadRequest(fmt.Sprintf("Invalid groupBy dimension: %s", dimension), nil)
+		}
+		if _, ok := seenDimensions[dimension]; ok {
+			return nil, response.BadRequest(fmt.Sprintf("Duplicate groupBy dimension: %s", dimension), nil)
+		}
+		seenDimensions[dimension] = struct{}{}
+		request.Dimensions = append(request.Dimensions, dimension)
+	}
+	if len(request.Dimensions) > maxBreakdownDimensions {
+		return nil, response.BadRequest(fmt.Sprintf("Maximum %d groupBy dimensions are allowed", maxBreakdownDimensions), nil)
+	}
+
+	res, err := s.repo.SalesBreakdownReport(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// id dikumpulkan lewat map supaya id 1 tidak dianggap duplikat karena sudah ada id 11
+	tableIds := []string{}
+	userIds := []string{}
+	seenTables := map[int64]struct{}{}
+	seenUsers := map[int64]struct{}{}
+	for _, data := range res {
+		for _, dimension := range data.Dimensions {
+			if dimension.Value == 0 {
+				continue
+			}
+			switch dimension.Name {
+			case dimensionTable:
+				if _, ok := seenTables[dimension.Value]; !ok {
+					seenTables[dimension.Value] = struct{}{}
+					tableIds = append(tableIds, utils.Int64ToString(dimension.Value))
+				}
+			case dimensionStaff:
+				if _, ok := seenUsers[dimension.Value]; !ok {
+					seenUsers[dimension.Value] = struct{}{}
+					userIds = append(userIds, utils.Int64ToString(dimension.Value))
+				}
+			}
+		}
+	}
+
+	var tables []TableResponse
+	if len(tableIds) > 0 {
+		dataMenusAndTable, err := getDataMenuByIdsAndTable("", strings.Join(tableIds, ","))
+		if err != nil {
+			return nil, err
+		}
+		tables = dataMenusAndTable.Tables
+	}
+
+	var users []UserResponse
+	if len(userIds) > 0 {
+		users, err = getUsersNameByIds(strings.Join(userIds, ","))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, data := range res {
+		for idDimension, dimension := range data.Dimensions {
+			res[i].Dimensions[idDimension].Label = breakdownLabel(dimension, tables, users)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *transactionService) PeakHoursReport(request PeakHoursReportRequest) ([]PeakHoursDay, error) {
+	cells, err := s.repo.PeakHoursReport(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// matrix selalu 7x24, jam tanpa transaksi diisi nol
+	res := make([]PeakHoursDay, 0, len(peakHoursWeekdays))
+	for i, label := range peakHoursWeekdays {
+		day := PeakHoursDay{Weekday: i + 1, Label: label, Hours: make([]PeakHoursCell, 24)}
+		for hour := range day.Hours {
+			day.Hours[hour].Weekday = day.Weekday
+			day.Hours[hour].Hour = hour
+		}
+		res = append(res, day)
+	}
+
+	for _, cell := range cells {
+		if cell.Weekday < 1 || cell.Weekday > len(res) || cell.Hour < 0 || cell.Hour > 23 {
+			continue
+		}
+		res[cell.Weekday-1].Hours[cell.Hour] = cell
+	}
+
+	return res, nil
+}
+
+func (s *transactionService) CohortReport(request CohortReportRequest) ([]CohortReport, error) {
+	return s.repo.CohortReport(request)
+}
+
+func breakdownLabel(dimension SalesBreakdownDimension, tables []TableResponse, users []UserResponse) string {
+	switch dimension.Name {
+	case dimensionTable:
+		for _, table := range tables {
+			if dimension.Value == table.Id {
+				return table.Name
+			}
+		}
+	case dimensionStaff:
+		if dimension.Value == 0 {
+			return "unprocessed"
+		}
+		for _, user := range users {
+			if dimension.Value == user.UserId {
+				return user.FullName
+			}
+		}
+	case dimensionStatus:
+		return orderStatusLabels[dimension.Value]
+	case dimensionHour:
+		return fmt.Sprintf("%02d:00", dimension.Value)
+	}
+	return utils.Int64ToString(dimension.Value)
+}
+
+// enrichUserTransactions melengkapi nama menu dan meja untuk history milik user yang sedang login
+func enrichUserTransactions(res []TransactionResponse, name string, expand transactionExpand) error {
+	// user selalu pemilik transaksi, jadi nama diambil dari claims tanpa memanggil account
+	userExpand := expand.User
+	expand.User = false
+	if err := enrichTransactions(res, expand); err != nil {
+		return err
+	}
+
+	if userExpand {
+		for i := range res {
+			res[i].OrderBy = name
+		}
+	}
+
+	return nil
+}
+
+// enrichTransactions melengkapi nama menu, meja dan user dari master-data dan account.
+// Hanya relasi yang diminta di expand yang diambil
+func enrichTransactions(res []TransactionResponse, expand transactionExpand) error {
+	// id dikumpulkan lewat map supaya id 1 tidak dianggap duplikat karena sudah ada id 11
+	menuIds := []string{}
+	tableIds := []string{}
+	userIds := []string{}
+	seenMenus := map[int64]struct{}{}
+	seenTables := map[int64]struct{}{}
+	seenUsers := map[int64]struct{}{}
+	for _, data := range res {
+		if _, ok := seenTables[data.TableId]; expand.Table && data.TableId != 0 && !ok {
+			seenTables[data.TableId] = struct{}{}
+			tableIds = append(tableIds, utils.Int64ToString(data.TableId))
+		}
+
+		if _, ok := seenUsers[data.UserId]; expand.User && data.UserId != 0 && !ok {
+			seenUsers[data.UserId] = struct{}{}
+			userIds = append(userIds, utils.Int64ToString(data.UserId))
+		}
+
+		if !expand.Menus {
+			continue
+		}
+		for _, detail := range data.Details {
+			if _, ok := seenMenus[int64(detail.MenuId)]; detail.MenuId != 0 && !ok {
+				seenMenus[int64(detail.MenuId)] = struct{}{}
+				menuIds = append(menuIds, utils.IntToString(detail.MenuId))
+			}
+		}
+	}
+
+	menuIdsStr := strings.Join(menuIds, ",")
+	tableIdsStr := strings.Join(tableIds, ",")
+	userIdsStr := strings.Join(userIds, ",")
+
+	var dataMenusAndTable GetMenusAndTableResponse
+	if menuIdsStr != "" || tableIdsStr != "" {
+		var err error
+		dataMenusAndTable, err = getDataMenuByIdsAndTable(menuIdsStr, tableIdsStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	var dataUsers []UserResponse
+	if userIdsStr != "" {
+		var err error
+		dataUsers, err = getUsersNameByIds(userIdsStr)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, data := range res {
+		for idDataDetail, dataDetail := range data.Details {
+			for _, menu := range dataMenusAndTable.Menus {
+				if dataDetail.MenuId == menu.Id {
+					res[i].Details[idDataDetail].MenuName = menu.Name
+					res[i].Details[idDataDetail].Photo = menu.Photo
+					res[i].Details[idDataDetail].Description = menu.Description
+					break
+				}
+			}
+		}
+		for _, table := range dataMenusAndTable.Tables {
+			if data.TableId == table.Id {
+				res[i].TableName = table.Name
+				break
+			}
+		}
+		for _, user := range dataUsers {
+			if data.UserId == user.UserId {
+				res[i].OrderBy = user.FullName
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// newTransactionExpand membaca param expand. Kalau expand tidak dikirim semua relasi diambil,
+// kecuali param fields tidak meminta key yang butuh relasi tersebut
+func newTransactionExpand(params common.ParamsListRequest) (transactionExpand, error) {
+	if err := common.ValidateFields[TransactionResponse](params.Fields); err != nil {
+		return transactionExpand{}, err
+	}
+
+	if params.Expand == nil {
+		return transactionExpand{
+			Menus: common.HasField(params.Fields, "details"),
+			Table: common.HasField(params.Fields, "tableName"),
+			User:  common.HasField(params.Fields, "orderBy"),
+		}, nil
+	}
+
+	expand := transactionExpand{}
+	for _, name := range params.Expand {
+		switch name {
+		case expandMenus:
+			expand.Menus = true
+		case expandTable:
+			expand.Table = true
+		case expandUser:
+			expand.User = true
+		default:
+			return transactionExpand{}, response.BadRequest("Invalid expand parameter: "+name, nil)
+		}
+	}
+
+	return expand, nil
+}
+
+// renderReceipt membuat PDF struk, struk pesanan yang sudah selesai disimpan di MinIO
+// karena isinya tidak berubah lagi
+func renderReceipt(res *TransactionResponse) ([]byte, error) {
+	cacheable := config.Config.ReceiptCacheEnabled && res.OrderStatus == orderStatusCompleted
+	filePath := fmt.Sprintf("%s/%d.pdf", receiptPath, res.Id)
+
+	if cacheable {
+		file, err := lib.GetFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			return file, nil
+		}
+	}
+
+	records := []TransactionResponse{*res}
+	err := enrichTransactions(records, expandAll)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := generateReceiptPDF(&records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		// gagal menyimpan cache tidak menggagalkan download struk
+		if _, err := lib.UploadBytes(filePath, file, lib.MIMEPDF); err != nil {
+			log.Warn("Failed to cache receipt:", err)
+		}
+	}
+
+	return file, nil
+}
+
+// enrichDailyClosings melengkapi nama user yang melakukan closing
+func enrichDailyClosings(res []DailyClosing) error {
+	userIds := []string{}
+	seenUsers := map[int64]struct{}{}
+	for _, data := range res {
+		if _, ok := seenUsers[data.ClosedBy]; data.ClosedBy != 0 && !ok {
+			seenUsers[data.ClosedBy] = struct{}{}
+			userIds = append(userIds, utils.Int64ToString(data.ClosedBy))
+		}
+	}
+
+	if len(userIds) == 0 {
+		return nil
+	}
+
+	dataUsers, err := getUsersNameByIds(strings.Join(userIds, ","))
+	if err != nil {
+		return err
+	}
+
+	for i, data := range res {
+		for _, user := range dataUsers {
+			if data.ClosedBy == user.UserId {
+				res[i].ClosedByName = user.FullName
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// transactionExportRows membuat satu baris export untuk setiap detail transaksi
+func transactionExportRows(records []TransactionResponse) [][]interface{} {
+	rows := make([][]interface{}, 0, len(records))
+	for _, data := range records {
+		for _, detail := range data.Details {
+			var rating interface{} = ""
+			if detail.Rating != nil {
+				rating = *detail.Rating
+			}
+			rows = append(rows, []interface{}{
+				data.Id,
+				data.CreatedAt,
+				data.TableName,
+				data.OrderBy,
+				data.OrderFor,
+				orderStatusLabels[int64(data.OrderStatus)],
+				data.TotalPrice,
+				detail.Id,
+				detail.MenuId,
+				detail.MenuName,
+				detail.Qty,
+				detail.Price,
+				detail.TotalPrice,
+				detail.Notes,
+				rating,
+			})
+		}
+	}
+	return rows
+}
+
+func calculateTotalPriceMenu(menus []MenuResponse, request *CreateTransactionRequest) float64 {
+	var total float64
+	for _, menu := range menus {
+		for iD, data := range request.Datas {
+			if menu.Id == data.MenuID {
+				request.Datas[iD].Price = menu.Price
+				request.Datas[iD].Total = menu.Price * float64(data.Qty)
+				// nama menu disimpan untuk full-text search dan kalau menu dihapus dari master-data
+				request.Datas[iD].MenuName = menu.Name
+				total += menu.Price * float64(data.Qty)
+			}
+		}
+	}
+
+	return total
+}
+
+func publishEvent(queueName string, event interface{}) error {
+	ch, err := lib.GetChannel()
+	if err != nil {
+		log.Error("Failed to get channel:", err)
+		return response.InternalServerError("Internal Server Error", nil)
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Failed to marshal event payload:", err)
+		return response.InternalServerError("Internal Server Error", nil)
+	}
+	return lib.SendMessage(ch, queueName, queueName, "", lib.ExchangeDirect, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        payload,
+	}, string(payload), true, false, false, amqp.Table{})
+}
+
+func uploadRatingPhotos(detailId int, photos []*multipart.FileHeader) ([]string, []string, error) {
+	urls := make([]string, 0, len(photos))
+	paths := make([]string, 0, len(photos))
+	for i, photo := range photos {
+		filePath := fmt.Sprintf("%s/%d/%d-%d%s", ratingPhotoPath, detailId, time.Now().UnixNano(), i, filepath.Ext(photo.Filename))
+		photoUrl, err := lib.UploadFile(filePath, photo)
+		if err != nil {
+			deleteRatingPhotos(paths)
+			return nil, nil, err
+		}
+		urls = append(urls, photoUrl)
+		paths = append(paths, filePath)
+	}
+	return urls, paths, nil
+}
+
+func ratingPhotoPaths(urls []string) []string {
+	prefix := config.Config.MinioBaseURL + "/" + config.Config.MinioBucketName + "/"
+	paths := make([]string, 0, len(urls))
+	for _, photoUrl := range urls {
+		if strings.HasPrefix(photoUrl, prefix) {
+			paths = append(paths, strings.TrimPrefix(photoUrl, prefix))
+		}
+	}
+	return paths
+}
+
+func validateRatingEligibility(eligibility RatingEligibility, userId int64) error {
+	if eligibility.UserId != userId {
+		return response.Forbidden("You are not allowed to rate this order", nil)
+	}
+	if eligibility.OrderStatus != orderStatusCompleted {
+		return response.BadRequest("Only completed orders can be rated", nil)
+	}
+	if !eligibility.WithinWindow {
+		return response.BadRequest(fmt.Sprintf("Rating is only allowed within %d days after the order is completed", config.Config.RatingWindowDays), nil)
+	}
+	return nil
+}
+
+func deleteRatingPhotos(paths []string) {
+	for _, filePath := range paths {
+		if err := lib.DeleteFile(filePath); err != nil {
+			log.Error("Failed to delete rating photo:", filePath)
+		}
+	}
+}
+
+func createSignature(params string, body string, timestamp string) (string, error) {
+
+	message := params + timestamp + body
+	
+	slog.Info("Generating HMAC Signature", 
+		slog.String("payload", message),
+		slog.String("params", params),
+		slog.String("body", body),
+	)
+
+	signature, err := utils.GenerateHMAC(message)
+	if err != nil {
+		slog.Error("Failed to generate HMAC", slog.String("error", err.Error()))
+		return "", response.InternalServerError("Internal Server Error", nil)
+	}
+
+	return signature, nil
+}
+
+func paymentUseWallet(userId int64, total float64, pin string) (PaymentResponse, error) {
+	// Implement the logic to check the user's wallet balance
+	urlWallet := fmt.Sprintf("%s/api/internal/pay", config.Config.ServiceWalletUrl)
+
+	// Create the request body
+	bodyRequest := PaymentRequest{
+		UserId: userId,
+		Amount: total,
+		Pin:    pin,
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+
+	// Marshal ke JSON (sekali saja)
+	bodyBytes, err := json.Marshal(bodyRequest)
+	if err != nil {
+		return PaymentResponse{}, err
+	}
+
+	// Simpan versi string-nya untuk signature
+	bodyString := string(bodyBytes)
+
+	signature, err := createSignature("", bodyString, timestamp)
+
+	if err != nil {
+		return PaymentResponse{}, err
+	}
+
+	body, err := utils.InternalRequest(signature, timestamp, urlWallet, "POST", bytes.NewReader(bodyBytes))
+
+	if err != nil {
+		return PaymentResponse{}, err
+	}
+
+	var payment InternalPaymentResponse
+	err = json.Unmarshal(body, &payment)
+	if err != nil {
+		log.Error("Failed to unmarshal response body:", err)
+		return PaymentResponse{}, response.InternalServerError("Internal Server Error", nil)
+	}
+
+	if payment.Data.PaymentMethod == "" {
+		payment.Data.PaymentMethod = defaultPaymentMethod
+	}
+
+	return payment.Data, nil
+}
+
 func getAvailableMenuByIdsAndTableById(ids string, tableId int64) ([]MenuResponse, error) {
 	params := url.Values{}
 	params.Add("ids", ids)
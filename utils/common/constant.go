@@ -0,0 +1,62 @@
+package common
+
+// tipe field yang dipakai di mappingFieldType setiap module
+const (
+	FieldTypeString    = "string"
+	FieldTypeInt       = "int"
+	FieldTypeFloat     = "float"
+	FieldTypeDecimal   = "decimal"
+	FieldTypeBool      = "bool"
+	FieldTypeDate      = "date"
+	FieldTypeTimestamp = "timestamp"
+)
+
+// operator search dari query param searchOperator
+const (
+	OperatorEq      = "eq"
+	OperatorNe      = "ne"
+	OperatorGt      = "gt"
+	OperatorGte     = "gte"
+	OperatorLt      = "lt"
+	OperatorLte     = "lte"
+	OperatorLike    = "like"
+	OperatorIn      = "in"
+	OperatorBetween = "between"
+	OperatorIsNull  = "isnull"
+
+	// SearchValueSeparator memisahkan beberapa value untuk operator in dan between
+	SearchValueSeparator = "|"
+	// SearchListSeparator memisahkan item di searchKey, searchValue dan searchOperator
+	SearchListSeparator = ","
+	// FilterPartSeparator memisahkan field, operator dan value di param filter
+	FilterPartSeparator = ":"
+	// EscapeCharacter dipakai untuk menulis separator sebagai isi value, contoh: Jl. Merdeka\, No. 1
+	EscapeCharacter = '\\'
+)
+
+var searchOperators = map[string]string{
+	OperatorEq:  "=",
+	OperatorNe:  "<>",
+	OperatorGt:  ">",
+	OperatorGte: ">=",
+	OperatorLt:  "<",
+	OperatorLte: "<=",
+}
+
+var timestampLayouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+const (
+	SortAsc  = "ASC"
+	SortDesc = "DESC"
+
+	// pemisah antar kolom sort, contoh: sort=createdAt,desc;totalPrice,asc
+	SortSeparator = ";"
+)
+
+// kolom COUNT(*) OVER() yang ditambahkan QueryPagination ke query list
+const totalDataColumn = "total_data"
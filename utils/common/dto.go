@@ -6,14 +6,24 @@ import (
 
 type ParamsListRequest struct {
 	Search     Search // field, value
-	Sort       Sort   // field, order
+	Sort       []Sort // field, order
 	Size       int
 	Page       int
 	NoPaginate bool
+	UseCursor  bool
+	Cursor     string
+	// Query adalah kata kunci full-text search dari param q
+	Query       string
+	DefaultSort bool
+	// Fields membatasi key JSON di response, kosong berarti semua field
+	Fields []string
+	// Expand berisi data relasi yang dilengkapi, nil berarti param expand tidak dikirim
+	Expand []string
 }
 type Search struct {
-	Field []string
-	Value []string
+	Field    []string
+	Value    []string
+	Operator []string
 }
 
 type Sort struct {
@@ -21,6 +31,14 @@ type Sort struct {
 	Order string
 }
 
+// Cursor adalah isi cursor keyset pagination sebelum di-encode base64
+type Cursor struct {
+	Field string  `json:"f"`
+	Order string  `json:"o"`
+	Value *string `json:"v"`
+	Id    int64   `json:"i"`
+}
+
 type OneRequest struct {
 	Id int `query:"id" validate:"required"`
 }
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// ValidateFields memastikan semua nama di param fields ada di key JSON milik T
+func ValidateFields[T any](fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	allowed := jsonFieldNames(reflect.TypeOf((*T)(nil)).Elem())
+	for _, field := range fields {
+		if !allowed[field] {
+			return response.BadRequest("Invalid fields parameter: "+field, nil)
+		}
+	}
+	return nil
+}
+
+// SelectFields memotong key JSON tiap item sesuai param fields. records bisa berupa slice
+// atau response.Pagination / response.CursorPagination (yang dipotong isi key data)
+func SelectFields(records interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return records, nil
+	}
+
+	body, err := json.Marshal(records)
+	if err != nil {
+		log.Error("Failed to marshal records:", err)
+		return nil, response.InternalServerError("Failed to select fields", nil)
+	}
+
+	var list []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &list); err == nil {
+		return selectItemFields(list, fields), nil
+	}
+
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		log.Error("Failed to unmarshal records:", err)
+		return nil, response.InternalServerError("Failed to select fields", nil)
+	}
+	if err := json.Unmarshal(wrapper["data"], &list); err != nil {
+		log.Error("Failed to unmarshal records data:", err)
+		return nil, response.InternalServerError("Failed to select fields", nil)
+	}
+
+	data, err := json.Marshal(selectItemFields(list, fields))
+	if err != nil {
+		log.Error("Failed to marshal records data:", err)
+		return nil, response.InternalServerError("Failed to select fields", nil)
+	}
+	wrapper["data"] = data
+
+	return wrapper, nil
+}
+
+// HasField dipakai untuk cek apakah field diminta, fields kosong berarti semua field
+func HasField(fields []string, name string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func selectItemFields(list []map[string]json.RawMessage, fields []string) []map[string]json.RawMessage {
+	result := make([]map[string]json.RawMessage, 0, len(list))
+	for _, item := range list {
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := item[field]; ok {
+				selected[field] = value
+			}
+		}
+		result = append(result, selected)
+	}
+	return result
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && tag == "" {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		names[tag] = true
+	}
+	return names
+}
@@ -0,0 +1,214 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FilterParam adalah satu filter di param filters (JSON), contoh:
+// filters=[{"field":"orderFor","op":"like","value":"meja, 1"},{"field":"totalPrice","op":"between","value":[10000,25000]}]
+type FilterParam struct {
+	Field    string          `json:"field"`
+	Operator string          `json:"op"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// parseSearchParams membaca searchKey, searchValue dan searchOperator yang dipisah koma.
+// Koma di dalam value ditulis \, dan jumlah item di ketiga param harus sama
+func parseSearchParams(queryParams map[string]string, params *ParamsListRequest) error {
+	var err error
+	if searchField, ok := queryParams["searchKey"]; ok {
+		if params.Search.Field, err = splitEscaped(searchField, SearchListSeparator, true); err != nil {
+			return err
+		}
+	}
+	if searchValue, ok := queryParams["searchValue"]; ok {
+		// escape lain dibiarkan untuk dipecah lagi oleh operator in/between
+		if params.Search.Value, err = splitEscaped(searchValue, SearchListSeparator, false); err != nil {
+			return err
+		}
+	}
+	if searchOperator, ok := queryParams["searchOperator"]; ok {
+		if params.Search.Operator, err = splitEscaped(searchOperator, SearchListSeparator, true); err != nil {
+			return err
+		}
+	}
+
+	if len(params.Search.Field) != len(params.Search.Value) {
+		return response.BadRequest(fmt.Sprintf("searchKey has %d items but searchValue has %d", len(params.Search.Field), len(params.Search.Value)), nil)
+	}
+	if len(params.Search.Operator) > 0 && len(params.Search.Operator) != len(params.Search.Field) {
+		return response.BadRequest(fmt.Sprintf("searchKey has %d items but searchOperator has %d", len(params.Search.Field), len(params.Search.Operator)), nil)
+	}
+
+	return nil
+}
+
+// parseFilterParams membaca param filter=field:operator:value (boleh diulang) dan filters (JSON).
+// Hasilnya digabung ke params.Search supaya diproses sama seperti searchKey/searchValue
+func parseFilterParams(c *fiber.Ctx, params *ParamsListRequest) error {
+	for _, raw := range c.Context().QueryArgs().PeekMulti("filter") {
+		filter := string(raw)
+		parts, err := splitEscaped(filter, FilterPartSeparator, false)
+		if err != nil {
+			return err
+		}
+		if len(parts) < 3 || parts[0] == "" {
+			return response.BadRequest(fmt.Sprintf("Invalid filter %q, use field:operator:value", filter), nil)
+		}
+
+		field, err := unescapeValue(parts[0])
+		if err != nil {
+			return err
+		}
+		operator, err := unescapeValue(parts[1])
+		if err != nil {
+			return err
+		}
+		// value boleh berisi ':' tanpa escape, contoh createdAt:gte:2024-01-01T10:00:00
+		addSearch(params, field, operator, strings.Join(parts[2:], FilterPartSeparator))
+	}
+
+	rawFilters := c.Query("filters")
+	if rawFilters == "" {
+		return nil
+	}
+
+	var filters []FilterParam
+	if err := json.Unmarshal([]byte(rawFilters), &filters); err != nil {
+		return response.BadRequest("Invalid filters parameter, must be a JSON array of {field, op, value}", nil)
+	}
+	for i, filter := range filters {
+		if filter.Field == "" {
+			return response.BadRequest(fmt.Sprintf("Invalid filters parameter, item %d has no field", i), nil)
+		}
+		value, err := filterParamValue(filter.Value)
+		if err != nil {
+			return response.BadRequest(fmt.Sprintf("Invalid filters parameter, item %d: %s", i, err.Error()), nil)
+		}
+		addSearch(params, filter.Field, filter.Operator, value)
+	}
+
+	return nil
+}
+
+// addSearch menambah satu kondisi ke params.Search, operator diisi kosong untuk item
+// searchKey lama supaya panjang slice tetap sama
+func addSearch(params *ParamsListRequest, field string, operator string, value string) {
+	for len(params.Search.Operator) < len(params.Search.Field) {
+		params.Search.Operator = append(params.Search.Operator, "")
+	}
+	params.Search.Field = append(params.Search.Field, field)
+	params.Search.Value = append(params.Search.Value, value)
+	params.Search.Operator = append(params.Search.Operator, operator)
+}
+
+// filterParamValue mengubah value JSON ke format searchValue. Array dipakai untuk operator
+// in/between dan setiap item di-escape supaya '|' di dalam item tidak dianggap pemisah
+func filterParamValue(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var items []interface{}
+	if err := decodeFilterJSON(raw, &items); err == nil {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			value, err := scalarFilterValue(item)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, escapeValue(value, SearchValueSeparator))
+		}
+		return strings.Join(values, SearchValueSeparator), nil
+	}
+
+	var item interface{}
+	if err := decodeFilterJSON(raw, &item); err != nil {
+		return "", err
+	}
+	value, err := scalarFilterValue(item)
+	if err != nil {
+		return "", err
+	}
+	// '|' di value string tetap jadi pemisah in/between, hanya backslash yang di-escape
+	return escapeValue(value, ""), nil
+}
+
+// decodeFilterJSON memakai UseNumber supaya angka tetap ditulis seperti di request,
+// float64 akan menulis angka besar seperti 10000000 sebagai 1e+07
+func decodeFilterJSON(raw json.RawMessage, dest interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(dest)
+}
+
+func scalarFilterValue(item interface{}) (string, error) {
+	switch value := item.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("value must be a string, number, boolean or array of them")
+	}
+}
+
+// splitEscaped memecah value dengan separator yang tidak diawali backslash. Kalau unescape true
+// semua escape dibuang, kalau false hanya escape separator yang dibuang supaya value bisa dipecah lagi
+func splitEscaped(value string, separator string, unescape bool) ([]string, error) {
+	parts := make([]string, 0)
+	var current strings.Builder
+	for i := 0; i < len(value); i++ {
+		char := value[i]
+		if char == EscapeCharacter {
+			if i+1 >= len(value) {
+				return nil, response.BadRequest(fmt.Sprintf("Invalid escape at the end of %q", value), nil)
+			}
+			next := value[i+1]
+			if !unescape && string(next) != separator {
+				current.WriteByte(char)
+			}
+			current.WriteByte(next)
+			i++
+			continue
+		}
+		if string(char) == separator {
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(char)
+	}
+
+	return append(parts, current.String()), nil
+}
+
+// unescapeValue membuang backslash escape dari satu value
+func unescapeValue(value string) (string, error) {
+	if !strings.ContainsRune(value, EscapeCharacter) {
+		return value, nil
+	}
+
+	parts, err := splitEscaped(value, "", true)
+	if err != nil {
+		return "", err
+	}
+	return parts[0], nil
+}
+
+// escapeValue menambahkan backslash sebelum backslash dan separator
+func escapeValue(value string, separator string) string {
+	value = strings.ReplaceAll(value, string(EscapeCharacter), string(EscapeCharacter)+string(EscapeCharacter))
+	if separator != "" {
+		value = strings.ReplaceAll(value, separator, string(EscapeCharacter)+separator)
+	}
+	return value
+}
@@ -0,0 +1,251 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2"
+)
+
+// assertBadRequest memastikan err adalah AppError dengan status 400
+func assertBadRequest(t *testing.T, err error) {
+	t.Helper()
+	var appErr *response.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected *response.AppError, got %T (%v)", err, err)
+	}
+	if appErr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d (%s)", http.StatusBadRequest, appErr.Code, appErr.Message)
+	}
+}
+
+func TestSplitEscaped(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		separator string
+		unescape  bool
+		want      []string
+		wantErr   bool
+	}{
+		{name: "plain", value: "a,b,c", separator: ",", unescape: true, want: []string{"a", "b", "c"}},
+		{name: "empty value", value: "", separator: ",", unescape: true, want: []string{""}},
+		{name: "empty items kept", value: "a,,b,", separator: ",", unescape: true, want: []string{"a", "", "b", ""}},
+		{name: "escaped separator", value: `Jl. Merdeka\, No. 1,meja 2`, separator: ",", unescape: true, want: []string{"Jl. Merdeka, No. 1", "meja 2"}},
+		{name: "escaped backslash", value: `a\\,b`, separator: ",", unescape: true, want: []string{`a\`, "b"}},
+		{name: "unescape drops other escapes", value: `a\|b,c`, separator: ",", unescape: true, want: []string{"a|b", "c"}},
+		{name: "keep other escapes", value: `a\|b\,c,d`, separator: ",", unescape: false, want: []string{`a\|b,c`, "d"}},
+		{name: "keep escaped backslash", value: `a\\,b`, separator: ",", unescape: false, want: []string{`a\\`, "b"}},
+		{name: "filter parts", value: `order\:for:like:meja 1`, separator: ":", unescape: false, want: []string{"order:for", "like", "meja 1"}},
+		{name: "trailing escape", value: `abc\`, separator: ",", unescape: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitEscaped(tt.value, tt.separator, tt.unescape)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeValueRoundTrip(t *testing.T) {
+	values := []string{"plain", "a|b", `back\slash`, `a\|b`, `ends with \`}
+	for _, value := range values {
+		escaped := escapeValue(value, SearchValueSeparator)
+		parts, err := splitEscaped(escaped+SearchValueSeparator+"next", SearchValueSeparator, true)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", value, err)
+		}
+		if len(parts) != 2 || parts[0] != value || parts[1] != "next" {
+			t.Fatalf("%q: got %q after escape %q", value, parts, escaped)
+		}
+	}
+}
+
+func TestParseSearchParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		queryParams map[string]string
+		want        Search
+		wantErr     bool
+	}{
+		{
+			name:        "escaped comma in value",
+			queryParams: map[string]string{"searchKey": "orderFor,orderStatus", "searchValue": `meja\, 1,1`, "searchOperator": "like,eq"},
+			want:        Search{Field: []string{"orderFor", "orderStatus"}, Value: []string{"meja, 1", "1"}, Operator: []string{"like", "eq"}},
+		},
+		{
+			name:        "value keeps pipe escape for in",
+			queryParams: map[string]string{"searchKey": "orderFor", "searchValue": `a\|b|c`, "searchOperator": "in"},
+			want:        Search{Field: []string{"orderFor"}, Value: []string{`a\|b|c`}, Operator: []string{"in"}},
+		},
+		{
+			name:        "without operator",
+			queryParams: map[string]string{"searchKey": "orderFor", "searchValue": "meja"},
+			want:        Search{Field: []string{"orderFor"}, Value: []string{"meja"}},
+		},
+		{
+			name:        "value count mismatch",
+			queryParams: map[string]string{"searchKey": "orderFor,orderStatus", "searchValue": "meja"},
+			wantErr:     true,
+		},
+		{
+			name:        "operator count mismatch",
+			queryParams: map[string]string{"searchKey": "orderFor,orderStatus", "searchValue": "meja,1", "searchOperator": "like"},
+			wantErr:     true,
+		},
+		{
+			name:        "trailing escape",
+			queryParams: map[string]string{"searchKey": "orderFor", "searchValue": `meja\`},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ParamsListRequest{}
+			err := parseSearchParams(tt.queryParams, &params)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(params.Search, tt.want) {
+				t.Fatalf("got %+v, want %+v", params.Search, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterParamValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "null", raw: "null", want: ""},
+		{name: "string", raw: `"meja, 1"`, want: "meja, 1"},
+		{name: "string keeps pipe", raw: `"a|b"`, want: "a|b"},
+		{name: "string escapes backslash", raw: `"a\\b"`, want: `a\\b`},
+		{name: "large integer", raw: "10000000", want: "10000000"},
+		{name: "large integer in array", raw: "[10000000,25000000]", want: "10000000|25000000"},
+		{name: "decimal", raw: "12500.75", want: "12500.75"},
+		{name: "bool", raw: "true", want: "true"},
+		{name: "array escapes pipe", raw: `["a|b","c"]`, want: `a\|b|c`},
+		{name: "nested array", raw: "[[1,2]]", wantErr: true},
+		{name: "object", raw: `{"a":1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterParamValue(json.RawMessage(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  []string
+		filters string
+		want    Search
+		wantErr bool
+	}{
+		{
+			name:   "repeated filter",
+			filter: []string{"orderFor:like:meja, 1", "orderStatus:in:1|2"},
+			want:   Search{Field: []string{"orderFor", "orderStatus"}, Value: []string{"meja, 1", "1|2"}, Operator: []string{"like", "in"}},
+		},
+		{
+			name:   "value with unescaped colon",
+			filter: []string{"createdAt:gte:2024-01-01T10:00:00+07:00"},
+			want:   Search{Field: []string{"createdAt"}, Value: []string{"2024-01-01T10:00:00+07:00"}, Operator: []string{"gte"}},
+		},
+		{
+			name:   "escaped colon in field",
+			filter: []string{`order\:for:eq:a\:b`},
+			want:   Search{Field: []string{"order:for"}, Value: []string{"a:b"}, Operator: []string{"eq"}},
+		},
+		{
+			name:    "json filters",
+			filters: `[{"field":"orderFor","op":"like","value":"meja, 1"},{"field":"totalPrice","op":"between","value":[10000000,25000000]}]`,
+			want:    Search{Field: []string{"orderFor", "totalPrice"}, Value: []string{"meja, 1", "10000000|25000000"}, Operator: []string{"like", "between"}},
+		},
+		{
+			name:    "filter and json filters merged",
+			filter:  []string{"orderStatus:eq:1"},
+			filters: `[{"field":"orderFor","op":"in","value":["a|b","c"]}]`,
+			want:    Search{Field: []string{"orderStatus", "orderFor"}, Value: []string{"1", `a\|b|c`}, Operator: []string{"eq", "in"}},
+		},
+		{name: "missing value", filter: []string{"orderFor:like"}, wantErr: true},
+		{name: "missing field", filter: []string{":eq:1"}, wantErr: true},
+		{name: "invalid json", filters: `{"field":"orderFor"}`, wantErr: true},
+		{name: "json without field", filters: `[{"op":"eq","value":1}]`, wantErr: true},
+		{name: "json object value", filters: `[{"field":"orderFor","op":"eq","value":{"a":1}}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := make([]string, 0, len(tt.filter)+1)
+			for _, filter := range tt.filter {
+				query = append(query, "filter="+url.QueryEscape(filter))
+			}
+			if tt.filters != "" {
+				query = append(query, "filters="+url.QueryEscape(tt.filters))
+			}
+
+			params := ParamsListRequest{}
+			var err error
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				err = parseFilterParams(c, &params)
+				return nil
+			})
+			if _, testErr := app.Test(httptest.NewRequest(http.MethodGet, "/?"+strings.Join(query, "&"), nil)); testErr != nil {
+				t.Fatalf("request failed: %v", testErr)
+			}
+
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(params.Search, tt.want) {
+				t.Fatalf("got %+v, want %+v", params.Search, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"eka-dev.cloud/transaction-service/utils/response"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// PaginationQuery berisi query list dan count yang sudah termasuk filter khusus module.
+// Filter search, sort dan limit dari ParamsListRequest ditambahkan oleh QueryPagination,
+// Name dipakai untuk pesan error (contoh: "transaction")
+type PaginationQuery struct {
+	Name             string
+	Query            string
+	CountQuery       string
+	GroupBy          string
+	Args             map[string]interface{}
+	MappingFieldType *map[string]string
+}
+
+// QueryPagination menjalankan query list lalu membentuk response.Pagination. Total data dihitung
+// dengan COUNT(*) OVER() di query yang sama, jadi Query harus diawali SELECT utama.
+// CountQuery hanya dipakai kalau halaman yang diminta kosong (page melewati data terakhir).
+// BuildMappingField tetap dipanggil oleh repository sebelum memanggil fungsi ini
+func QueryPagination[T any](db *sqlx.DB, params ParamsListRequest, query PaginationQuery) (*response.Pagination[[]T], error) {
+	var record = make([]T, 0)
+
+	listQuery := strings.Replace(query.Query, "SELECT", fmt.Sprintf("SELECT COUNT(*) OVER() AS %s,", totalDataColumn), 1)
+	finalQuery, args, err := BuildFilterQuery(listQuery, params, query.MappingFieldType, query.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range query.Args {
+		args[key] = value
+	}
+
+	rows, err := db.NamedQuery(finalQuery, args)
+	if err != nil {
+		log.Error("Failed to get list "+query.Name+":", err)
+		return nil, response.InternalServerError("Failed to get list "+query.Name, nil)
+	}
+	defer func(rows *sqlx.Rows) {
+		err := rows.Close()
+		if err != nil {
+			log.Error("failed to close rows:", err)
+			return
+		}
+	}(rows)
+
+	var totalData int
+	for rows.Next() {
+		var data T
+		if err := scanWithTotal(rows, &data, &totalData); err != nil {
+			log.Error("Failed to scan "+query.Name+":", err)
+			return nil, response.InternalServerError("Failed to scan "+query.Name, nil)
+		}
+		record = append(record, data)
+	}
+
+	// COUNT(*) OVER() tidak ikut terbaca kalau halaman kosong, total diambil dari count query
+	if len(record) == 0 && params.Page > 1 && query.CountQuery != "" {
+		totalData, err = countPagination(db, params, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pagination := NewPagination(record, totalData, params)
+	return &pagination, nil
+}
+
+func countPagination(db *sqlx.DB, params ParamsListRequest, query PaginationQuery) (int, error) {
+	var totalData int
+	countFinalQuery, countArgs, err := BuildCountQuery(query.CountQuery, params, query.MappingFieldType)
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range query.Args {
+		countArgs[key] = value
+	}
+
+	countStmt, err := db.PrepareNamed(countFinalQuery)
+	if err != nil {
+		log.Error("Failed to prepare count query:", err)
+		return 0, response.InternalServerError("Failed to get list "+query.Name+" count", nil)
+	}
+	defer func(countStmt *sqlx.NamedStmt) {
+		err := countStmt.Close()
+		if err != nil {
+			log.Error("failed to close count statement:", err)
+			return
+		}
+	}(countStmt)
+
+	if err := countStmt.Get(&totalData, countArgs); err != nil {
+		log.Error("Failed to get total data:", err)
+		return 0, response.InternalServerError("Failed to get list "+query.Name+" count", nil)
+	}
+
+	return totalData, nil
+}
+
+// scanWithTotal sama seperti StructScan, tapi kolom total_data dibaca ke total
+func scanWithTotal(rows *sqlx.Rows, dest interface{}, total *int) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(dest))
+	fields := rows.Mapper.TraversalsByName(value.Type(), columns)
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if column == totalDataColumn {
+			values[i] = total
+			continue
+		}
+		if len(fields[i]) == 0 {
+			return fmt.Errorf("missing destination name %s in %T", column, dest)
+		}
+		values[i] = reflectx.FieldByIndexes(value, fields[i]).Addr().Interface()
+	}
+
+	return rows.Scan(values...)
+}
+
+// NewPagination menghitung info halaman, size <= 0 berarti semua data ada di satu halaman
+func NewPagination[T any](data T, totalData int, params ParamsListRequest) response.Pagination[T] {
+	totalPages := 0
+	if params.Size > 0 {
+		totalPages = (totalData + params.Size - 1) / params.Size
+	} else if totalData > 0 {
+		totalPages = 1
+	}
+
+	return response.Pagination[T]{
+		TotalData:   totalData,
+		Data:        data,
+		CurrentPage: params.Page,
+		PageSize:    params.Size,
+		TotalPages:  totalPages,
+		LastPage:    params.Page >= totalPages,
+	}
+}
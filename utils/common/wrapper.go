@@ -2,200 +2,508 @@ package common
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"eka-dev.cloud/transaction-service/config"
 	"eka-dev.cloud/transaction-service/utils/response"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/jmoiron/sqlx"
 )
 
-func BuildFilterQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string, groupByQuery string) (string, map[string]interface{}) {
-	// Implementation here
-	args := map[string]interface{}{}
-	filteredField := []string{}
-	for _, field := range params.Search.Field {
-		if field != "" {
-			filteredField = append(filteredField, field)
+func BuildFilterQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string, groupByQuery string) (string, map[string]interface{}, error) {
+	baseQuery, args, err := buildWhereQuery(baseQuery, params, mappingFieldType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if groupByQuery != "" {
+		baseQuery += " " + groupByQuery + " "
+	}
+
+	if len(params.Sort) > 0 {
+		orders := make([]string, 0, len(params.Sort))
+		for _, sort := range params.Sort {
+			orders = append(orders, fmt.Sprintf("%s %s", sort.Field, sort.Order))
 		}
+		baseQuery += fmt.Sprintf(" ORDER BY %s ", strings.Join(orders, ", "))
 	}
-	if len(filteredField) > 0 && len(filteredField) == len(params.Search.Value) {
+	if params.Size > 0 && params.Page > 0 && !params.NoPaginate {
+		offset := (params.Page - 1) * params.Size
+		baseQuery += " LIMIT :size OFFSET :offset "
+		args["size"] = params.Size
+		args["offset"] = offset
+	}
+	return baseQuery, args, nil
+}
+
+func BuildCountQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string) (string, map[string]interface{}, error) {
+	return buildWhereQuery(baseQuery, params, mappingFieldType)
+}
+
+// BuildCursorQuery membuat query keyset pagination. Hanya satu kolom sort yang didukung,
+// idField dipakai sebagai tie breaker supaya urutan tetap stabil. Query mengambil size+1 row
+// untuk tahu apakah masih ada halaman berikutnya, nilai sort dikembalikan di kolom cursor_value
+func BuildCursorQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string, idField string, groupByQuery string) (string, map[string]interface{}, error) {
+	if params.Size <= 0 {
+		return "", nil, response.BadRequest("Size must be greater than 0", nil)
+	}
+	if len(params.Sort) > 1 {
+		return "", nil, response.BadRequest("Cursor pagination only supports one sort field", nil)
+	}
+
+	sort := Sort{Field: idField, Order: SortDesc}
+	if len(params.Sort) == 1 {
+		sort = params.Sort[0]
+	}
+
+	baseQuery = strings.Replace(baseQuery, "SELECT", fmt.Sprintf("SELECT CAST(%s AS TEXT) AS cursor_value,", sort.Field), 1)
+
+	baseQuery, args, err := buildWhereQuery(baseQuery, params, mappingFieldType)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if params.Cursor != "" {
+		cursor, err := DecodeCursor(params.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if cursor.Field != sort.Field || cursor.Order != sort.Order {
+			return "", nil, response.BadRequest("Cursor does not match the current sort", nil)
+		}
+
+		operator := ">"
+		if sort.Order == SortDesc {
+			operator = "<"
+		}
+
 		if !strings.Contains(strings.ToUpper(baseQuery), "WHERE") {
 			baseQuery += " WHERE  1=1 "
 		}
-		for i, field := range filteredField {
-			if params.Search.Value[i] != "" {
-				if mappingFieldType != nil {
-					if fieldType, ok := (*mappingFieldType)[field]; ok {
-						switch fieldType {
-						case "string":
-							baseQuery += fmt.Sprintf(" AND CAST(%s AS TEXT) ILIKE :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = "%" + params.Search.Value[i] + "%"
-						case "int":
-							intValue, err := strconv.Atoi(params.Search.Value[i])
-							if err != nil {
-								log.Warnf("Invalid int value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = intValue
-						case "float":
-							floatValue, err := strconv.ParseFloat(params.Search.Value[i], 64)
-							if err != nil {
-								log.Warnf("Invalid float value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = floatValue
-						case "bool":
-							boolValue, err := strconv.ParseBool(params.Search.Value[i])
-							if err != nil {
-								log.Warnf("Invalid bool value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = boolValue
-						default:
-							// default to string if type is unknown
-							baseQuery += fmt.Sprintf(" AND CAST(%s AS TEXT) ILIKE :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = "%" + params.Search.Value[i] + "%"
-						}
-					}
-				}
+		if sort.Field == idField {
+			baseQuery += fmt.Sprintf(" AND %s %s :cursor_id ", idField, operator)
+		} else {
+			fieldType := ""
+			if mappingFieldType != nil {
+				fieldType = (*mappingFieldType)[sort.Field]
+			}
+			baseQuery += " AND " + cursorCondition(sort, idField, operator, searchPlaceholder(fieldType, "cursor_value"), cursor.Value != nil)
+			if cursor.Value != nil {
+				args["cursor_value"] = *cursor.Value
 			}
 		}
+		args["cursor_id"] = cursor.Id
 	}
 
 	if groupByQuery != "" {
 		baseQuery += " " + groupByQuery + " "
 	}
 
-	if params.Sort.Order != "" && params.Sort.Field != "" {
-		baseQuery += fmt.Sprintf(" ORDER BY %s %s ", params.Sort.Field, strings.ToUpper(params.Sort.Order))
+	if sort.Field == idField {
+		baseQuery += fmt.Sprintf(" ORDER BY %s %s ", idField, sort.Order)
+	} else {
+		baseQuery += fmt.Sprintf(" ORDER BY %s %s %s, %s %s ", sort.Field, sort.Order, cursorNullsOrder(sort.Order), idField, sort.Order)
 	}
-	if params.Size > 0 && params.Page > 0 && !params.NoPaginate {
-		offset := (params.Page - 1) * params.Size
-		baseQuery += " LIMIT :size OFFSET :offset "
-		args["size"] = params.Size
-		args["offset"] = offset
+	baseQuery += " LIMIT :size "
+	args["size"] = params.Size + 1
+
+	return baseQuery, args, nil
+}
+
+// cursorNullsOrder menyamakan posisi NULL dengan default postgres (NULL dianggap nilai terbesar),
+// ditulis eksplisit karena cursorCondition bergantung pada urutan ini
+func cursorNullsOrder(order string) string {
+	if order == SortDesc {
+		return "NULLS FIRST"
+	}
+	return "NULLS LAST"
+}
+
+// cursorCondition membuat kondisi row setelah cursor. Perbandingan row (field, id) bernilai NULL
+// kalau field NULL, jadi row dengan nilai NULL ditangani terpisah sesuai cursorNullsOrder
+func cursorCondition(sort Sort, idField string, operator string, placeholder string, hasValue bool) string {
+	switch {
+	case hasValue && sort.Order == SortDesc:
+		// NULL ada di awal, sudah terlewati
+		return fmt.Sprintf(" (%s, %s) %s (%s, :cursor_id) ", sort.Field, idField, operator, placeholder)
+	case hasValue:
+		// NULL ada di akhir, selalu setelah cursor yang tidak NULL
+		return fmt.Sprintf(" ((%s, %s) %s (%s, :cursor_id) OR %s IS NULL) ", sort.Field, idField, operator, placeholder, sort.Field)
+	case sort.Order == SortDesc:
+		return fmt.Sprintf(" ((%s IS NULL AND %s %s :cursor_id) OR %s IS NOT NULL) ", sort.Field, idField, operator, sort.Field)
+	default:
+		return fmt.Sprintf(" (%s IS NULL AND %s %s :cursor_id) ", sort.Field, idField, operator)
+	}
+}
+
+// NextCursor membuat cursor halaman berikutnya dari nilai sort dan id row terakhir,
+// value yang tidak valid berarti nilai sort row terakhir NULL
+func NextCursor(params ParamsListRequest, idField string, value sql.NullString, id int64) (string, error) {
+	cursor := Cursor{Field: idField, Order: SortDesc, Id: id}
+	if value.Valid {
+		cursor.Value = &value.String
+	}
+	if len(params.Sort) == 1 {
+		cursor.Field = params.Sort[0].Field
+		cursor.Order = params.Sort[0].Order
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		log.Error("Failed to encode cursor:", err)
+		return "", response.InternalServerError("Failed to encode cursor", nil)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, response.BadRequest("Invalid cursor", nil)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, response.BadRequest("Invalid cursor", nil)
 	}
-	return baseQuery, args
+
+	return &cursor, nil
 }
 
-func BuildCountQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string) (string, map[string]interface{}) {
-	// Implementation here
+// buildWhereQuery menambahkan kondisi search ke query, dipakai bersama oleh query list dan count
+func buildWhereQuery(baseQuery string, params ParamsListRequest, mappingFieldType *map[string]string) (string, map[string]interface{}, error) {
 	args := map[string]interface{}{}
-	filteredField := []string{}
-	for _, field := range params.Search.Field {
-		if field != "" {
-			filteredField = append(filteredField, field)
+	if len(params.Search.Field) == 0 {
+		return baseQuery, args, nil
+	}
+	if len(params.Search.Field) != len(params.Search.Value) {
+		return "", nil, response.BadRequest("searchValue must have the same length as searchKey", nil)
+	}
+	if len(params.Search.Operator) > 0 && len(params.Search.Operator) != len(params.Search.Field) {
+		return "", nil, response.BadRequest("searchOperator must have the same length as searchKey", nil)
+	}
+
+	conditions := ""
+	for i, field := range params.Search.Field {
+		// field sudah divalidasi BuildMappingField, tipe yang tidak ada di mapping dianggap string
+		fieldType := ""
+		if mappingFieldType != nil {
+			fieldType = (*mappingFieldType)[field]
+		}
+
+		operator := ""
+		if len(params.Search.Operator) > 0 {
+			operator = strings.ToLower(strings.TrimSpace(params.Search.Operator[i]))
+		}
+
+		condition, err := buildSearchCondition(field, fieldType, operator, params.Search.Value[i], fmt.Sprintf("searchValue%d", i), args)
+		if err != nil {
+			return "", nil, err
 		}
+		conditions += condition
 	}
-	if len(filteredField) > 0 && len(filteredField) == len(params.Search.Value) {
-		if !strings.Contains(strings.ToUpper(baseQuery), "WHERE") {
-			baseQuery += " WHERE  1=1 "
+
+	if conditions != "" && !strings.Contains(strings.ToUpper(baseQuery), "WHERE") {
+		baseQuery += " WHERE  1=1 "
+	}
+
+	return baseQuery + conditions, args, nil
+}
+
+func buildSearchCondition(field string, fieldType string, operator string, value string, argName string, args map[string]interface{}) (string, error) {
+	// default to string if type is unknown
+	isText := fieldType == FieldTypeString || !isKnownFieldType(fieldType)
+	if operator == "" {
+		operator = OperatorEq
+		if isText {
+			operator = OperatorLike
+		}
+	}
+
+	if operator == OperatorIsNull {
+		isNull, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", response.BadRequest(fmt.Sprintf("Invalid value %q for operator isnull", value), nil)
+		}
+		if isNull {
+			return fmt.Sprintf(" AND %s IS NULL ", field), nil
 		}
-		for i, field := range filteredField {
-			if params.Search.Value[i] != "" {
-				if mappingFieldType != nil {
-					if fieldType, ok := (*mappingFieldType)[field]; ok {
-						switch fieldType {
-						case "string":
-							baseQuery += fmt.Sprintf(" AND CAST(%s AS TEXT) ILIKE :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = "%" + params.Search.Value[i] + "%"
-						case "int":
-							intValue, err := strconv.Atoi(params.Search.Value[i])
-							if err != nil {
-								log.Warnf("Invalid int value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = intValue
-						case "float":
-							floatValue, err := strconv.ParseFloat(params.Search.Value[i], 64)
-							if err != nil {
-								log.Warnf("Invalid float value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = floatValue
-						case "bool":
-							boolValue, err := strconv.ParseBool(params.Search.Value[i])
-							if err != nil {
-								log.Warnf("Invalid bool value for field %s: %v", field, err)
-								continue
-							}
-							baseQuery += fmt.Sprintf(" AND %s = :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = boolValue
-						default:
-							// default to string if type is unknown
-							baseQuery += fmt.Sprintf(" AND CAST(%s AS TEXT) ILIKE :searchValue%d ", field, i)
-							args[fmt.Sprintf("searchValue%d", i)] = "%" + params.Search.Value[i] + "%"
-						}
-					}
-				}
+		return fmt.Sprintf(" AND %s IS NOT NULL ", field), nil
+	}
+
+	// value kosong ditolak supaya filter tidak diam-diam diabaikan, pakai isnull untuk cek NULL
+	if value == "" {
+		return "", response.BadRequest(fmt.Sprintf("Search value cannot be empty for operator %s", operator), nil)
+	}
+
+	column := field
+	if isText {
+		column = fmt.Sprintf("CAST(%s AS TEXT)", field)
+	} else if fieldType == FieldTypeDate {
+		column = fmt.Sprintf("CAST(%s AS DATE)", field)
+	}
+
+	switch operator {
+	case OperatorLike:
+		if !isText {
+			return "", response.BadRequest(fmt.Sprintf("Operator %s is not supported for this field", operator), nil)
+		}
+		value, err := unescapeValue(value)
+		if err != nil {
+			return "", err
+		}
+		args[argName] = "%" + value + "%"
+		return fmt.Sprintf(" AND %s ILIKE :%s ", column, argName), nil
+	case OperatorIn, OperatorBetween:
+		values, err := splitEscaped(value, SearchValueSeparator, true)
+		if err != nil {
+			return "", err
+		}
+		if operator == OperatorBetween && len(values) != 2 {
+			return "", response.BadRequest(fmt.Sprintf("Operator between needs exactly 2 values separated by %s", SearchValueSeparator), nil)
+		}
+
+		placeholders := make([]string, 0, len(values))
+		for j, item := range values {
+			name := fmt.Sprintf("%s_%d", argName, j)
+			parsed, err := parseSearchValue(fieldType, item)
+			if err != nil {
+				return "", err
 			}
+			args[name] = parsed
+			placeholders = append(placeholders, searchPlaceholder(fieldType, name))
 		}
+
+		if operator == OperatorBetween {
+			return fmt.Sprintf(" AND %s BETWEEN %s AND %s ", column, placeholders[0], placeholders[1]), nil
+		}
+		return fmt.Sprintf(" AND %s IN (%s) ", column, strings.Join(placeholders, ", ")), nil
+	}
+
+	value, err := unescapeValue(value)
+	if err != nil {
+		return "", err
 	}
-	return baseQuery, args
+
+	sqlOperator, ok := searchOperators[operator]
+	if !ok {
+		return "", response.BadRequest(fmt.Sprintf("Invalid search operator %q", operator), nil)
+	}
+	if fieldType == FieldTypeBool && operator != OperatorEq && operator != OperatorNe {
+		return "", response.BadRequest(fmt.Sprintf("Operator %s is not supported for this field", operator), nil)
+	}
+
+	parsed, err := parseSearchValue(fieldType, value)
+	if err != nil {
+		return "", err
+	}
+	args[argName] = parsed
+
+	return fmt.Sprintf(" AND %s %s %s ", column, sqlOperator, searchPlaceholder(fieldType, argName)), nil
 }
 
-func BuildMappingField(params ParamsListRequest, mappingField *map[string]string) {
+// parseSearchValue memvalidasi value sesuai tipe field, value yang salah langsung 400
+func parseSearchValue(fieldType string, value string) (interface{}, error) {
+	invalid := response.BadRequest(fmt.Sprintf("Invalid value %q for %s field", value, fieldType), nil)
+
+	switch fieldType {
+	case FieldTypeInt:
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return intValue, nil
+	case FieldTypeFloat:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		return floatValue, nil
+	case FieldTypeDecimal:
+		// dikirim sebagai string supaya tidak kehilangan presisi, di-cast ke NUMERIC di query
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, invalid
+		}
+		return value, nil
+	case FieldTypeBool:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalid
+		}
+		return boolValue, nil
+	case FieldTypeDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return nil, invalid
+		}
+		return value, nil
+	case FieldTypeTimestamp:
+		for _, layout := range timestampLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return value, nil
+			}
+		}
+		return nil, invalid
+	default:
+		return value, nil
+	}
+}
+
+func searchPlaceholder(fieldType string, name string) string {
+	switch fieldType {
+	case FieldTypeDecimal:
+		return fmt.Sprintf("CAST(:%s AS NUMERIC)", name)
+	case FieldTypeDate:
+		return fmt.Sprintf("CAST(:%s AS DATE)", name)
+	case FieldTypeTimestamp:
+		// lewat TIMESTAMPTZ dulu supaya offset seperti +07:00 dikonversi ke timezone database,
+		// CAST langsung ke TIMESTAMP membuang offset tanpa error. Value tanpa offset tidak berubah
+		return fmt.Sprintf("CAST(CAST(:%s AS TIMESTAMPTZ) AS TIMESTAMP)", name)
+	default:
+		return ":" + name
+	}
+}
+
+func isKnownFieldType(fieldType string) bool {
+	switch fieldType {
+	case FieldTypeString, FieldTypeInt, FieldTypeFloat, FieldTypeDecimal, FieldTypeBool, FieldTypeDate, FieldTypeTimestamp:
+		return true
+	}
+	return false
+}
+
+// BuildMappingField mengubah nama field dari query param ke kolom database. Field search dan
+// sort yang tidak dikenal ditolak supaya filter dari client tidak diam-diam diabaikan
+func BuildMappingField(params ParamsListRequest, mappingField *map[string]string) error {
 	for i, field := range params.Search.Field {
-		if mapped, ok := (*mappingField)[field]; ok {
-			params.Search.Field[i] = mapped
-		} else {
-			log.Warn("Field ", field, " not found in mappingField")
-			params.Search.Field[i] = ""
+		mapped, ok := (*mappingField)[field]
+		if !ok {
+			return response.BadRequest(fmt.Sprintf("Invalid search field %q", field), nil)
+		}
+		params.Search.Field[i] = mapped
+	}
+	for i, sort := range params.Sort {
+		mapped, ok := (*mappingField)[sort.Field]
+		if !ok {
+			return response.BadRequest(fmt.Sprintf("Invalid sort field %q", sort.Field), nil)
 		}
+		params.Sort[i].Field = mapped
 	}
+	return nil
 }
 
-func ParseQueryParams(queryParams map[string]string, params *ParamsListRequest) error {
+// ParseQueryParams membaca param list. Param yang formatnya salah langsung 400 supaya
+// filter tidak diam-diam diabaikan
+func ParseQueryParams(c *fiber.Ctx, params *ParamsListRequest) error {
+	queryParams := c.Queries()
+
 	if page, ok := queryParams["page"]; ok {
 		pg, err := strconv.Atoi(page)
-		if err != nil {
-			return response.BadRequest("Invalid page parameter", nil)
+		if err != nil || pg < 1 {
+			return response.BadRequest("Invalid page parameter, page must be a number greater than 0", nil)
 		}
 		params.Page = pg
 	} else {
 		params.Page = 1 // default page
 	}
+	maxPageSize := config.Config.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = 100
+	}
 	if size, ok := queryParams["size"]; ok {
 		sz, err := strconv.Atoi(size)
-		if err != nil {
-			return response.BadRequest("Invalid size parameter", nil)
+		if err != nil || sz < 1 || sz > maxPageSize {
+			return response.BadRequest(fmt.Sprintf("Invalid size parameter, size must be between 1 and %d", maxPageSize), nil)
 		}
 		params.Size = sz
 	} else {
 		params.Size = 10 // default size
 	}
-	if sortField, ok := queryParams["sort"]; ok {
-		parts := strings.Split(sortField, ",")
-		if len(parts) == 2 {
-			params.Sort.Field = parts[0]
-			params.Sort.Order = parts[1]
+	if sortField, ok := queryParams["sort"]; ok && sortField != "" {
+		sorts, err := parseSort(sortField)
+		if err != nil {
+			return err
 		}
+		params.Sort = sorts
 	} else {
-		params.Sort.Field = "id"   // default sort field
-		params.Sort.Order = "DESC" // default sort order
+		params.Sort = []Sort{{Field: "id", Order: SortDesc}} // default sort
+		params.DefaultSort = true
 	}
-	if searchField, ok := queryParams["searchKey"]; ok {
-		params.Search.Field = strings.Split(searchField, ",")
+	if query, ok := queryParams["q"]; ok {
+		params.Query = strings.TrimSpace(query)
+	}
+	if err := parseSearchParams(queryParams, params); err != nil {
+		return err
 	}
-	if searchValue, ok := queryParams["searchValue"]; ok {
-		params.Search.Value = strings.Split(searchValue, ",")
+	if err := parseFilterParams(c, params); err != nil {
+		return err
 	}
 	if noPaginate, ok := queryParams["noPaginate"]; ok {
-		if noPaginate == "true" {
-			params.NoPaginate = true
+		value, err := strconv.ParseBool(noPaginate)
+		if err != nil {
+			return response.BadRequest("Invalid noPaginate parameter, use true or false", nil)
 		}
+		params.NoPaginate = value
+	}
+	if fields, ok := queryParams["fields"]; ok {
+		params.Fields = splitList(fields)
+	}
+	// expand= kosong tetap diisi slice kosong supaya bisa dibedakan dengan param yang tidak dikirim
+	if expand, ok := queryParams["expand"]; ok {
+		params.Expand = splitList(expand)
+	}
+	// cursor kosong berarti halaman pertama dengan keyset pagination
+	if cursor, ok := queryParams["cursor"]; ok {
+		params.UseCursor = true
+		params.Cursor = cursor
 	}
 	return nil
 }
 
+// splitList memecah nilai dipisah koma dan membuang item kosong
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseSort membaca format field,order;field,order. Order default ASC kalau tidak diisi
+func parseSort(value string) ([]Sort, error) {
+	sorts := []Sort{}
+	for _, item := range strings.Split(value, SortSeparator) {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ",")
+		if len(parts) > 2 {
+			return nil, response.BadRequest(fmt.Sprintf("Invalid sort parameter %q", item), nil)
+		}
+
+		sort := Sort{Field: strings.TrimSpace(parts[0]), Order: SortAsc}
+		if len(parts) == 2 {
+			sort.Order = strings.ToUpper(strings.TrimSpace(parts[1]))
+		}
+		if sort.Field == "" || (sort.Order != SortAsc && sort.Order != SortDesc) {
+			return nil, response.BadRequest(fmt.Sprintf("Invalid sort parameter %q", item), nil)
+		}
+
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
 func WithTransaction[P any](db *sqlx.DB, fn func(tx *sqlx.Tx, args P) error, args P) error {
 	tx, err := db.Beginx()
 	if err != nil {
@@ -0,0 +1,581 @@
+package common
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldType string
+		value     string
+		want      interface{}
+		wantErr   bool
+	}{
+		{name: "int", fieldType: FieldTypeInt, value: "10000000", want: int64(10000000)},
+		{name: "int rejects float", fieldType: FieldTypeInt, value: "1.5", wantErr: true},
+		{name: "int rejects exponent", fieldType: FieldTypeInt, value: "1e+07", wantErr: true},
+		{name: "float", fieldType: FieldTypeFloat, value: "12.5", want: 12.5},
+		{name: "float rejects text", fieldType: FieldTypeFloat, value: "abc", wantErr: true},
+		{name: "decimal kept as string", fieldType: FieldTypeDecimal, value: "12500.10", want: "12500.10"},
+		{name: "decimal rejects text", fieldType: FieldTypeDecimal, value: "12,5", wantErr: true},
+		{name: "bool", fieldType: FieldTypeBool, value: "true", want: true},
+		{name: "bool rejects text", fieldType: FieldTypeBool, value: "yes", wantErr: true},
+		{name: "date", fieldType: FieldTypeDate, value: "2024-01-31", want: "2024-01-31"},
+		{name: "date rejects time", fieldType: FieldTypeDate, value: "2024-01-31 10:00:00", wantErr: true},
+		{name: "date rejects invalid day", fieldType: FieldTypeDate, value: "2024-02-30", wantErr: true},
+		{name: "timestamp with offset", fieldType: FieldTypeTimestamp, value: "2024-01-31T10:00:00+07:00", want: "2024-01-31T10:00:00+07:00"},
+		{name: "timestamp utc", fieldType: FieldTypeTimestamp, value: "2024-01-31T10:00:00Z", want: "2024-01-31T10:00:00Z"},
+		{name: "timestamp without offset", fieldType: FieldTypeTimestamp, value: "2024-01-31T10:00:00", want: "2024-01-31T10:00:00"},
+		{name: "timestamp with space", fieldType: FieldTypeTimestamp, value: "2024-01-31 10:00:00", want: "2024-01-31 10:00:00"},
+		{name: "timestamp date only", fieldType: FieldTypeTimestamp, value: "2024-01-31", want: "2024-01-31"},
+		{name: "timestamp rejects text", fieldType: FieldTypeTimestamp, value: "yesterday", wantErr: true},
+		{name: "string", fieldType: FieldTypeString, value: "meja 1", want: "meja 1"},
+		{name: "unknown type as string", fieldType: "", value: "meja 1", want: "meja 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSearchValue(tt.fieldType, tt.value)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSearchCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		fieldType string
+		operator  string
+		value     string
+		want      string
+		wantArgs  map[string]interface{}
+		wantErr   bool
+	}{
+		{
+			name: "default like for string", field: "t.order_for", fieldType: FieldTypeString, value: `meja\, 1`,
+			want:     " AND CAST(t.order_for AS TEXT) ILIKE :v ",
+			wantArgs: map[string]interface{}{"v": "%meja, 1%"},
+		},
+		{
+			name: "default eq for int", field: "t.id", fieldType: FieldTypeInt, value: "10",
+			want:     " AND t.id = :v ",
+			wantArgs: map[string]interface{}{"v": int64(10)},
+		},
+		{
+			name: "gte timestamp converts offset", field: "t.created_at", fieldType: FieldTypeTimestamp, operator: OperatorGte, value: "2024-01-31T10:00:00+07:00",
+			want:     " AND t.created_at >= CAST(CAST(:v AS TIMESTAMPTZ) AS TIMESTAMP) ",
+			wantArgs: map[string]interface{}{"v": "2024-01-31T10:00:00+07:00"},
+		},
+		{
+			name: "between decimal", field: "t.total_price", fieldType: FieldTypeDecimal, operator: OperatorBetween, value: "10000|25000.50",
+			want:     " AND t.total_price BETWEEN CAST(:v_0 AS NUMERIC) AND CAST(:v_1 AS NUMERIC) ",
+			wantArgs: map[string]interface{}{"v_0": "10000", "v_1": "25000.50"},
+		},
+		{
+			name: "between date", field: "t.created_at", fieldType: FieldTypeDate, operator: OperatorBetween, value: "2024-01-01|2024-01-31",
+			want:     " AND CAST(t.created_at AS DATE) BETWEEN CAST(:v_0 AS DATE) AND CAST(:v_1 AS DATE) ",
+			wantArgs: map[string]interface{}{"v_0": "2024-01-01", "v_1": "2024-01-31"},
+		},
+		{
+			name: "in int", field: "t.order_status", fieldType: FieldTypeInt, operator: OperatorIn, value: "0|1|2",
+			want:     " AND t.order_status IN (:v_0, :v_1, :v_2) ",
+			wantArgs: map[string]interface{}{"v_0": int64(0), "v_1": int64(1), "v_2": int64(2)},
+		},
+		{
+			name: "in string with escaped pipe", field: "t.order_for", fieldType: FieldTypeString, operator: OperatorIn, value: `a\|b|c`,
+			want:     " AND CAST(t.order_for AS TEXT) IN (:v_0, :v_1) ",
+			wantArgs: map[string]interface{}{"v_0": "a|b", "v_1": "c"},
+		},
+		{
+			name: "isnull true", field: "t.payment_reference", fieldType: FieldTypeString, operator: OperatorIsNull, value: "true",
+			want: " AND t.payment_reference IS NULL ", wantArgs: map[string]interface{}{},
+		},
+		{
+			name: "isnull false", field: "t.payment_reference", fieldType: FieldTypeString, operator: OperatorIsNull, value: "false",
+			want: " AND t.payment_reference IS NOT NULL ", wantArgs: map[string]interface{}{},
+		},
+		{name: "isnull invalid", field: "t.payment_reference", operator: OperatorIsNull, value: "maybe", wantErr: true},
+		{name: "empty value", field: "t.id", fieldType: FieldTypeInt, operator: OperatorEq, value: "", wantErr: true},
+		{name: "invalid int", field: "t.id", fieldType: FieldTypeInt, operator: OperatorGt, value: "1e+07", wantErr: true},
+		{name: "invalid timestamp in between", field: "t.created_at", fieldType: FieldTypeTimestamp, operator: OperatorBetween, value: "2024-01-01|later", wantErr: true},
+		{name: "between needs two values", field: "t.total_price", fieldType: FieldTypeDecimal, operator: OperatorBetween, value: "1|2|3", wantErr: true},
+		{name: "like on int", field: "t.id", fieldType: FieldTypeInt, operator: OperatorLike, value: "1", wantErr: true},
+		{name: "gt on bool", field: "t.is_paid", fieldType: FieldTypeBool, operator: OperatorGt, value: "true", wantErr: true},
+		{name: "unknown operator", field: "t.id", fieldType: FieldTypeInt, operator: "contains", value: "1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{}
+			got, err := buildSearchCondition(tt.field, tt.fieldType, tt.operator, tt.value, "v", args)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("got args %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildFilterQueryWhere(t *testing.T) {
+	mappingFieldType := map[string]string{"t.id": FieldTypeInt, "t.created_at": FieldTypeTimestamp}
+	params := ParamsListRequest{
+		Search: Search{
+			Field:    []string{"t.id", "t.created_at"},
+			Value:    []string{"5", "2024-01-31"},
+			Operator: []string{"gt", ""},
+		},
+	}
+
+	query, args, err := BuildFilterQuery("SELECT t.id FROM transaction t", params, &mappingFieldType, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "SELECT t.id FROM transaction t WHERE  1=1  AND t.id > :searchValue0  AND t.created_at = CAST(CAST(:searchValue1 AS TIMESTAMPTZ) AS TIMESTAMP) "
+	if query != want {
+		t.Fatalf("got %q, want %q", query, want)
+	}
+	if args["searchValue0"] != int64(5) || args["searchValue1"] != "2024-01-31" {
+		t.Fatalf("unexpected args %#v", args)
+	}
+
+	// query yang sudah punya WHERE tidak ditambah WHERE lagi
+	query, _, err = BuildFilterQuery("SELECT t.id FROM transaction t WHERE t.deleted_at IS NULL", params, &mappingFieldType, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(strings.ToUpper(query), "WHERE") != 1 {
+		t.Fatalf("expected a single WHERE, got %q", query)
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []Sort
+		wantErr bool
+	}{
+		{name: "default order", value: "createdAt", want: []Sort{{Field: "createdAt", Order: SortAsc}}},
+		{name: "lowercase order", value: "createdAt,desc", want: []Sort{{Field: "createdAt", Order: SortDesc}}},
+		{name: "multiple columns", value: "orderStatus,asc;createdAt,DESC", want: []Sort{{Field: "orderStatus", Order: SortAsc}, {Field: "createdAt", Order: SortDesc}}},
+		{name: "spaces and empty items", value: " totalPrice , desc ;;", want: []Sort{{Field: "totalPrice", Order: SortDesc}}},
+		{name: "only separators", value: ";", want: []Sort{}},
+		{name: "invalid order", value: "createdAt,sideways", wantErr: true},
+		{name: "too many parts", value: "createdAt,desc,asc", wantErr: true},
+		{name: "missing field", value: ",desc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSort(tt.value)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMappingField(t *testing.T) {
+	mappingField := map[string]string{"id": "t.id", "createdAt": "t.created_at", "orderFor": "t.order_for"}
+
+	tests := []struct {
+		name       string
+		params     ParamsListRequest
+		wantSearch []string
+		wantSort   []Sort
+		wantErr    bool
+	}{
+		{
+			name:       "known fields",
+			params:     ParamsListRequest{Search: Search{Field: []string{"orderFor"}}, Sort: []Sort{{Field: "createdAt", Order: SortDesc}, {Field: "id", Order: SortAsc}}},
+			wantSearch: []string{"t.order_for"},
+			wantSort:   []Sort{{Field: "t.created_at", Order: SortDesc}, {Field: "t.id", Order: SortAsc}},
+		},
+		{
+			name:    "unknown search field",
+			params:  ParamsListRequest{Search: Search{Field: []string{"userPassword"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort field",
+			params:  ParamsListRequest{Sort: []Sort{{Field: "DROP TABLE transaction", Order: SortAsc}}},
+			wantErr: true,
+		},
+		{
+			name:    "column name instead of param name",
+			params:  ParamsListRequest{Sort: []Sort{{Field: "t.created_at", Order: SortAsc}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := BuildMappingField(tt.params, &mappingField)
+			if tt.wantErr {
+				assertBadRequest(t, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.params.Search.Field, tt.wantSearch) {
+				t.Fatalf("got search %q, want %q", tt.params.Search.Field, tt.wantSearch)
+			}
+			if !reflect.DeepEqual(tt.params.Sort, tt.wantSort) {
+				t.Fatalf("got sort %+v, want %+v", tt.params.Sort, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestBuildFilterQueryOrderBy(t *testing.T) {
+	params := ParamsListRequest{
+		Sort: []Sort{{Field: "t.order_status", Order: SortAsc}, {Field: "t.created_at", Order: SortDesc}},
+		Page: 3,
+		Size: 20,
+	}
+
+	query, args, err := BuildFilterQuery("SELECT t.id FROM transaction t", params, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(query, " ORDER BY t.order_status ASC, t.created_at DESC ") {
+		t.Fatalf("unexpected ORDER BY in %q", query)
+	}
+	if args["size"] != 20 || args["offset"] != 40 {
+		t.Fatalf("unexpected args %#v", args)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	value := "2024-01-31 10:00:00"
+	tests := []struct {
+		name   string
+		params ParamsListRequest
+		value  sql.NullString
+		want   Cursor
+	}{
+		{
+			name:   "default sort by id",
+			params: ParamsListRequest{},
+			value:  sql.NullString{String: "42", Valid: true},
+			want:   Cursor{Field: "t.id", Order: SortDesc, Value: strPtr("42"), Id: 42},
+		},
+		{
+			name:   "sort value",
+			params: ParamsListRequest{Sort: []Sort{{Field: "t.created_at", Order: SortAsc}}},
+			value:  sql.NullString{String: value, Valid: true},
+			want:   Cursor{Field: "t.created_at", Order: SortAsc, Value: &value, Id: 42},
+		},
+		{
+			name:   "null sort value",
+			params: ParamsListRequest{Sort: []Sort{{Field: "t.payment_reference", Order: SortDesc}}},
+			want:   Cursor{Field: "t.payment_reference", Order: SortDesc, Id: 42},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := NextCursor(tt.params, "t.id", tt.value, 42)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.ContainsAny(encoded, "+/=") {
+				t.Fatalf("cursor %q is not URL safe", encoded)
+			}
+			got, err := DecodeCursor(encoded)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, value := range []string{"not base64!", base64.RawURLEncoding.EncodeToString([]byte("not json"))} {
+		_, err := DecodeCursor(value)
+		assertBadRequest(t, err)
+	}
+}
+
+func TestCursorCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    string
+		operator string
+		hasValue bool
+		want     string
+	}{
+		{name: "asc with value", order: SortAsc, operator: ">", hasValue: true, want: " ((t.payment_reference, t.id) > (:cursor_value, :cursor_id) OR t.payment_reference IS NULL) "},
+		{name: "desc with value", order: SortDesc, operator: "<", hasValue: true, want: " (t.payment_reference, t.id) < (:cursor_value, :cursor_id) "},
+		{name: "asc with null", order: SortAsc, operator: ">", want: " (t.payment_reference IS NULL AND t.id > :cursor_id) "},
+		{name: "desc with null", order: SortDesc, operator: "<", want: " ((t.payment_reference IS NULL AND t.id < :cursor_id) OR t.payment_reference IS NOT NULL) "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort := Sort{Field: "t.payment_reference", Order: tt.order}
+			got := cursorCondition(sort, "t.id", tt.operator, ":cursor_value", tt.hasValue)
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// cursorRow adalah row contoh untuk simulasi keyset pagination, value nil berarti NULL
+type cursorRow struct {
+	id    int64
+	value *int
+}
+
+// sortCursorRows mengurutkan row seperti ORDER BY field order NULLS FIRST/LAST, id order
+func sortCursorRows(rows []cursorRow, order string) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if (a.value == nil) != (b.value == nil) {
+			// NULL dianggap nilai terbesar, sama dengan cursorNullsOrder
+			return (a.value == nil) == (order == SortDesc)
+		}
+		if a.value != nil && *a.value != *b.value {
+			return (*a.value < *b.value) == (order == SortAsc)
+		}
+		return (a.id < b.id) == (order == SortAsc)
+	})
+}
+
+// rowAfterCursor mengevaluasi kondisi cursorCondition untuk satu row. Perbandingan row
+// (field, id) bernilai NULL (false) kalau field NULL, sama seperti di postgres
+func rowAfterCursor(row cursorRow, order string, cursor *Cursor) bool {
+	idAfter := row.id > cursor.Id
+	if order == SortDesc {
+		idAfter = row.id < cursor.Id
+	}
+	if cursor.Value == nil {
+		if order == SortDesc {
+			return (row.value == nil && idAfter) || row.value != nil
+		}
+		return row.value == nil && idAfter
+	}
+
+	cursorValue, _ := strconv.Atoi(*cursor.Value)
+	tupleAfter := false
+	if row.value != nil {
+		if *row.value == cursorValue {
+			tupleAfter = idAfter
+		} else {
+			tupleAfter = (*row.value > cursorValue) == (order == SortAsc)
+		}
+	}
+	if order == SortDesc {
+		return tupleAfter
+	}
+	return tupleAfter || row.value == nil
+}
+
+func TestCursorPagesWithNullSortKeys(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+	rows := []cursorRow{
+		{id: 1, value: intPtr(300)},
+		{id: 2, value: nil},
+		{id: 3, value: intPtr(100)},
+		{id: 4, value: intPtr(300)},
+		{id: 5, value: nil},
+		{id: 6, value: nil},
+		{id: 7, value: intPtr(200)},
+	}
+
+	for _, order := range []string{SortAsc, SortDesc} {
+		for _, size := range []int{1, 2, 3} {
+			t.Run(fmt.Sprintf("%s size %d", order, size), func(t *testing.T) {
+				params := ParamsListRequest{Size: size, Sort: []Sort{{Field: "t.total_price", Order: order}}}
+				mappingFieldType := map[string]string{"t.total_price": FieldTypeInt}
+
+				expected := append([]cursorRow(nil), rows...)
+				sortCursorRows(expected, order)
+
+				seen := make([]int64, 0, len(rows))
+				for page := 0; page <= len(rows); page++ {
+					query, args, err := BuildCursorQuery("SELECT t.id FROM transaction t", params, &mappingFieldType, "t.id", "")
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if args["size"] != size+1 {
+						t.Fatalf("expected size+1 rows, got %v", args["size"])
+					}
+					if !strings.Contains(query, fmt.Sprintf(" ORDER BY t.total_price %s %s, t.id %s ", order, cursorNullsOrder(order), order)) {
+						t.Fatalf("unexpected ORDER BY in %q", query)
+					}
+
+					candidates := expected
+					if params.Cursor != "" {
+						cursor, err := DecodeCursor(params.Cursor)
+						if err != nil {
+							t.Fatalf("unexpected error: %v", err)
+						}
+						if _, ok := args["cursor_value"]; ok != (cursor.Value != nil) {
+							t.Fatalf("cursor_value arg present=%v for cursor value %v", ok, cursor.Value)
+						}
+						candidates = make([]cursorRow, 0, len(expected))
+						for _, row := range expected {
+							if rowAfterCursor(row, order, cursor) {
+								candidates = append(candidates, row)
+							}
+						}
+					}
+
+					limit := size + 1
+					if len(candidates) < limit {
+						limit = len(candidates)
+					}
+					result := candidates[:limit]
+					hasNext := len(result) > size
+					if hasNext {
+						result = result[:size]
+					}
+					for _, row := range result {
+						seen = append(seen, row.id)
+					}
+					if !hasNext {
+						break
+					}
+
+					last := result[len(result)-1]
+					value := sql.NullString{}
+					if last.value != nil {
+						value = sql.NullString{String: strconv.Itoa(*last.value), Valid: true}
+					}
+					if params.Cursor, err = NextCursor(params, "t.id", value, last.id); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+
+				want := make([]int64, 0, len(expected))
+				for _, row := range expected {
+					want = append(want, row.id)
+				}
+				if !reflect.DeepEqual(seen, want) {
+					t.Fatalf("got ids %v, want %v", seen, want)
+				}
+			})
+		}
+	}
+}
+
+func TestBuildCursorQuery(t *testing.T) {
+	mappingFieldType := map[string]string{"t.created_at": FieldTypeTimestamp}
+	createdAt := "2024-01-31 10:00:00"
+	encode := func(cursor Cursor) string {
+		data, _ := json.Marshal(cursor)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	t.Run("first page sorted by id", func(t *testing.T) {
+		query, args, err := BuildCursorQuery("SELECT t.id FROM transaction t", ParamsListRequest{Size: 10}, &mappingFieldType, "t.id", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "SELECT CAST(t.id AS TEXT) AS cursor_value, t.id FROM transaction t ORDER BY t.id DESC  LIMIT :size "
+		if query != want {
+			t.Fatalf("got %q, want %q", query, want)
+		}
+		if args["size"] != 11 {
+			t.Fatalf("unexpected args %#v", args)
+		}
+	})
+
+	t.Run("next page sorted by id", func(t *testing.T) {
+		params := ParamsListRequest{Size: 10, Cursor: encode(Cursor{Field: "t.id", Order: SortDesc, Id: 50})}
+		query, args, err := BuildCursorQuery("SELECT t.id FROM transaction t", params, &mappingFieldType, "t.id", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(query, " AND t.id < :cursor_id ") || args["cursor_id"] != int64(50) {
+			t.Fatalf("unexpected query %q with args %#v", query, args)
+		}
+	})
+
+	t.Run("next page sorted by timestamp", func(t *testing.T) {
+		params := ParamsListRequest{
+			Size:   10,
+			Sort:   []Sort{{Field: "t.created_at", Order: SortAsc}},
+			Cursor: encode(Cursor{Field: "t.created_at", Order: SortAsc, Value: &createdAt, Id: 7}),
+		}
+		query, args, err := BuildCursorQuery("SELECT t.id FROM transaction t", params, &mappingFieldType, "t.id", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		condition := " ((t.created_at, t.id) > (CAST(CAST(:cursor_value AS TIMESTAMPTZ) AS TIMESTAMP), :cursor_id) OR t.created_at IS NULL) "
+		if !strings.Contains(query, condition) {
+			t.Fatalf("expected %q in %q", condition, query)
+		}
+		if args["cursor_value"] != createdAt || args["cursor_id"] != int64(7) {
+			t.Fatalf("unexpected args %#v", args)
+		}
+	})
+
+	errorTests := []struct {
+		name   string
+		params ParamsListRequest
+	}{
+		{name: "size zero", params: ParamsListRequest{}},
+		{name: "multiple sorts", params: ParamsListRequest{Size: 10, Sort: []Sort{{Field: "t.id", Order: SortAsc}, {Field: "t.created_at", Order: SortAsc}}}},
+		{name: "invalid cursor", params: ParamsListRequest{Size: 10, Cursor: "???"}},
+		{
+			name: "cursor from another sort",
+			params: ParamsListRequest{
+				Size:   10,
+				Sort:   []Sort{{Field: "t.created_at", Order: SortDesc}},
+				Cursor: encode(Cursor{Field: "t.created_at", Order: SortAsc, Value: &createdAt, Id: 7}),
+			},
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := BuildCursorQuery("SELECT t.id FROM transaction t", tt.params, &mappingFieldType, "t.id", "")
+			assertBadRequest(t, err)
+		})
+	}
+}
+
+func strPtr(value string) *string {
+	return &value
+}
@@ -18,6 +18,14 @@ type Pagination[T any] struct {
 	LastPage    bool `json:"lastPage"`
 }
 
+// CursorPagination dipakai untuk keyset pagination, tidak ada total data karena tidak ada COUNT
+type CursorPagination[T any] struct {
+	Data       T      `json:"data"`
+	PageSize   int    `json:"pageSize"`
+	NextCursor string `json:"nextCursor"`
+	HasMore    bool   `json:"hasMore"`
+}
+
 func Success(message string, data interface{}) Response {
 	return Response{
 		Message:   message,